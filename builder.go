@@ -0,0 +1,124 @@
+package sesame
+
+import (
+	"reflect"
+)
+
+// Builder builds a frozen [Mappers] .
+// Builder keeps the first error that occurs while building, and
+// [Builder.Build] returns it.
+//
+//	mappers, err := sesame.NewBuilder().
+//	    Merge(mapper.NewMappers()).
+//	    Add("TimeStringConverter", &TimeStringConverter{}).
+//	    Configure(plugin.AddConverters).
+//	    Build()
+type Builder struct {
+	mappers *mappers
+	err     error
+}
+
+// NewBuilder returns a new [Builder] .
+func NewBuilder(opts ...MappersOption) *Builder {
+	return &Builder{
+		mappers: newMappers(opts...),
+	}
+}
+
+// Add adds given object to mappers. See [Mappers.Add] .
+func (b *Builder) Add(id string, obj any, opts ...AddOption) *Builder {
+	b.setErr(b.mappers.Add(id, obj, opts...))
+	return b
+}
+
+// AddFactory adds given object factory to mappers. See [Mappers.AddFactory] .
+func (b *Builder) AddFactory(id string, typ reflect.Type, factory func(MapperGetter) (any, error),
+	opts ...AddOption) *Builder {
+	b.setErr(b.mappers.AddFactory(id, typ, factory, opts...))
+	return b
+}
+
+// AddFunc adds given function to mappers. See [Mappers.AddFunc] .
+func (b *Builder) AddFunc(id string, fn any, opts ...AddOption) *Builder {
+	b.setErr(b.mappers.AddFunc(id, fn, opts...))
+	return b
+}
+
+// Merge merges given mappers into mappers. See [Mappers.Merge] .
+func (b *Builder) Merge(other MapperGetter) *Builder {
+	b.setErr(b.mappers.Merge(other))
+	return b
+}
+
+// Configure calls f with mappers that are being built.
+// Errors returned by add, remove and merge actions in f are returned by [Builder.Build] .
+// f must not retain given mappers.
+func (b *Builder) Configure(f func(Mappers)) *Builder {
+	f(&builderMappers{mappers: b.mappers, builder: b})
+	return b
+}
+
+// Build freezes and returns mappers.
+// Build returns the first error that occurred while building.
+func (b *Builder) Build() (Mappers, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	b.mappers.Freeze()
+	return b.mappers, nil
+}
+
+func (b *Builder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// builderMappers records errors in the builder.
+type builderMappers struct {
+	*mappers
+	builder *Builder
+}
+
+func (m *builderMappers) Add(id string, obj any, opts ...AddOption) error {
+	err := m.mappers.Add(id, obj, opts...)
+	m.builder.setErr(err)
+	return err
+}
+
+func (m *builderMappers) AddFactory(id string, typ reflect.Type, factory func(MapperGetter) (any, error),
+	opts ...AddOption) error {
+	err := m.mappers.AddFactory(id, typ, factory, opts...)
+	m.builder.setErr(err)
+	return err
+}
+
+func (m *builderMappers) Merge(other MapperGetter) error {
+	err := m.mappers.Merge(other)
+	m.builder.setErr(err)
+	return err
+}
+
+func (m *builderMappers) AddFunc(id string, fn any, opts ...AddOption) error {
+	err := m.mappers.AddFunc(id, fn, opts...)
+	m.builder.setErr(err)
+	return err
+}
+
+func (m *builderMappers) Remove(id string) error {
+	err := m.mappers.Remove(id)
+	m.builder.setErr(err)
+	return err
+}
+
+func (m *builderMappers) Replace(id string, obj any, opts ...AddOption) error {
+	err := m.mappers.Replace(id, obj, opts...)
+	m.builder.setErr(err)
+	return err
+}
+
+func (m *builderMappers) MergeWith(other MapperGetter, opts ...MergeOption) (*MergeReport, error) {
+	report, err := m.mappers.MergeWith(other, opts...)
+	m.builder.setErr(err)
+	return report, err
+}
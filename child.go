@@ -0,0 +1,156 @@
+package sesame
+
+import (
+	"slices"
+)
+
+// Child returns a new [Mappers] that overlays this mappers.
+// Get actions of the child fall back to this mappers, but add actions of the
+// child affect only the child. Functions added to the child take precedence
+// over functions in this mappers. The child uses interceptors and a logger of this mappers.
+//
+// Objects added to this mappers are shared with the child. Objects that are
+// created by factories of this mappers are created again in the child, so
+// that they use objects overridden in the child:
+//
+//	child := mappers.Child()
+//	child.Add("TimeStringConverter", &FixedTimeStringConverter{})
+//	userMapper, err := sesame.Get[UserMapper](child, "UserMapper") // uses FixedTimeStringConverter
+//
+// If parents remove or replace objects, objects created in the child are
+// created again on the next Get.
+func (d *mappers) Child() Mappers {
+	c := &mappers{
+		parent:       d,
+		interceptors: d.interceptors,
+		logger:       d.logger,
+	}
+	c.parentsSeen.Store(c.parentsGeneration())
+	return c
+}
+
+// parentsGeneration returns a sum of generations of parents. It changes
+// whenever parents remove objects.
+func (d *mappers) parentsGeneration() uint64 {
+	var g uint64
+	for p := d.parent; p != nil; p = p.parent {
+		g += p.generation.Load()
+	}
+	return g
+}
+
+// syncParents forgets objects created by this mappers if parents removed
+// objects after that, because the objects may depend on removed objects.
+func (d *mappers) syncParents() {
+	if d.parent == nil {
+		return
+	}
+	g := d.parentsGeneration()
+	seen := d.parentsSeen.Load()
+	if g == seen || !d.parentsSeen.CompareAndSwap(seen, g) {
+		return
+	}
+	d.debug("Forget created objects because parents are modified")
+	d.createdLock.Lock()
+	created := slices.Clone(d.created)
+	d.createdLock.Unlock()
+	for _, c := range created {
+		c.forget()
+	}
+}
+
+// lookupParent returns an object or a factory with given id in parents.
+// Factories take precedence over objects because parents cache objects
+// created by their factories.
+func (d *mappers) lookupParent(id string) (any, any) {
+	for p := d.parent; p != nil; p = p.parent {
+		if f, ok := p.factories.Load(id); ok && f != nil {
+			return nil, f
+		}
+		if v, ok := p.dependencies.Load(id); ok && v != nil {
+			return v, nil
+		}
+	}
+	return nil, nil
+}
+
+// lookupFactory returns a factory with given id in this mappers or parents.
+func (d *mappers) lookupFactory(id string) *objectFactory {
+	for p := d; p != nil; p = p.parent {
+		if f, ok := p.factories.Load(id); ok && f != nil {
+			return f.(*objectFactory)
+		}
+	}
+	return nil
+}
+
+// lookupFuncs returns functions with given index. Functions in parents
+// come first.
+func (d *mappers) lookupFuncs(index string) []mfunc {
+	var lst []mfunc
+	if d.parent != nil {
+		lst = d.parent.lookupFuncs(index)
+	}
+	if v, ok := d.findex.Load(index); ok {
+		if lst == nil {
+			return v.([]mfunc)
+		}
+		lst = append(slices.Clip(lst), v.([]mfunc)...)
+	}
+	return lst
+}
+
+// flatten returns a new mappers that has objects, factories and functions
+// of this mappers and parents as this mappers sees them.
+// Objects that parents created by their factories are not included,
+// because this mappers creates them again.
+func (d *mappers) flatten() *mappers {
+	var chain []*mappers
+	for p := d; p != nil; p = p.parent {
+		chain = append(chain, p)
+	}
+	flat := newMappers()
+	for i := len(chain) - 1; i >= 0; i-- {
+		p := chain[i]
+		cached := p.cachedIDs()
+		p.factories.Range(func(k, v any) bool {
+			if v != nil {
+				flat.dependencies.Delete(k)
+				flat.factories.Store(k, v)
+			}
+			return true
+		})
+		p.dependencies.Range(func(k, v any) bool {
+			if v == nil {
+				return true
+			}
+			if !cached[k.(string)] {
+				flat.factories.Delete(k)
+			} else if i != 0 {
+				return true
+			}
+			flat.dependencies.Store(k, v)
+			return true
+		})
+		p.findex.Range(func(k, v any) bool {
+			lv, _ := flat.findex.LoadOrStore(k, []mfunc{})
+			flat.findex.Store(k, appendMfuncs(lv.([]mfunc), v.([]mfunc)...))
+			return true
+		})
+	}
+	return flat
+}
+
+// cachedIDs returns ids of objects that are created by factories and
+// cached as singletons.
+func (d *mappers) cachedIDs() map[string]bool {
+	d.createdLock.Lock()
+	defer d.createdLock.Unlock()
+	ids := map[string]bool{}
+	for _, c := range d.created {
+		if c.Cache == &d.dependencies {
+			ids[c.ID] = true
+		}
+	}
+	return ids
+}
@@ -3,9 +3,11 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
 
 	sesameinternal "github.com/yuin/sesame/internal"
@@ -13,13 +15,14 @@ import (
 
 func main() {
 	if len(os.Getenv("DEBUG")) != 0 {
-		sesameinternal.LogEnabledFor = sesameinternal.LogLevelDebug
+		sesameinternal.LogLevel.Set(slog.LevelDebug)
 	}
 
 	generateCmd := flag.NewFlagSet("generate", flag.ExitOnError)
-	generateConfig := generateCmd.String("c", "sesame.yml", "config file path")
-	generateHelp := generateCmd.Bool("h", false, "show this help")
-	generateQuiet := generateCmd.Bool("q", false, "suppress messages")
+	generateFlags := newCommonFlags(generateCmd)
+
+	checkCmd := flag.NewFlagSet("check", flag.ExitOnError)
+	checkFlags := newCommonFlags(checkCmd)
 
 	cmdName := "generate"
 	args := []string{}
@@ -33,34 +36,69 @@ redo:
 	case "generate":
 		err := generateCmd.Parse(args)
 		if err != nil {
-			sesameinternal.LogFunc(sesameinternal.LogLevelError, err.Error())
+			sesameinternal.Logger.Error(err.Error())
 			os.Exit(1)
 		}
-		if *generateHelp {
+		if generateFlags.Help {
 			generateCmd.Usage()
 			os.Exit(1)
 		}
-		if *generateQuiet {
-			sesameinternal.LogEnabledFor = sesameinternal.LogLevelError
-		}
+		setLogLevel(generateFlags.Quiet, slog.LevelError, generateFlags.Verbose)
+		format := parseFormat(generateFlags.Format, generateFlags.LogFormat)
 		var config sesameinternal.Generation
-		if err := sesameinternal.LoadConfig(&config, *generateConfig); err != nil {
-			sesameinternal.LogFunc(sesameinternal.LogLevelError, err.Error())
-			os.Exit(1)
+		if err := sesameinternal.LoadConfig(&config, generateFlags.Config); err != nil {
+			exitWithDiagnostics(format, sesameinternal.NewDiagnostics(err))
 		}
-		if sesameinternal.LogEnabledFor >= sesameinternal.LogLevelDebug {
+		if sesameinternal.LogLevel.Level() <= slog.LevelDebug {
 			b, _ := json.Marshal(&config)
-			sesameinternal.LogFunc(sesameinternal.LogLevelDebug, string(b))
+			sesameinternal.Logger.Debug("Config", "config", string(b))
 		}
 		generator := sesameinternal.NewGenerator(&config)
 		if err := generator.Generate(); err != nil {
-			sesameinternal.LogFunc(sesameinternal.LogLevelError, err.Error())
+			exitWithDiagnostics(format, sesameinternal.NewDiagnostics(err))
+		}
+		writeDiagnostics(format, nil)
+	case "check":
+		err := checkCmd.Parse(args)
+		if err != nil {
+			sesameinternal.Logger.Error(err.Error())
+			os.Exit(1)
+		}
+		if checkFlags.Help {
+			checkCmd.Usage()
+			os.Exit(1)
+		}
+		setLogLevel(checkFlags.Quiet, slog.LevelWarn, checkFlags.Verbose)
+		format := parseFormat(checkFlags.Format, checkFlags.LogFormat)
+		var config sesameinternal.Generation
+		if err := sesameinternal.LoadConfig(&config, checkFlags.Config); err != nil {
+			exitWithDiagnostics(format, sesameinternal.NewDiagnostics(err))
+		}
+		checker := sesameinternal.NewChecker(&config)
+		problems, err := checker.Check()
+		if err != nil {
+			exitWithDiagnostics(format, sesameinternal.NewDiagnostics(err))
+		}
+		if format != sesameinternal.DiagnosticFormatText {
+			errs := make([]error, 0, len(problems))
+			for _, problem := range problems {
+				errs = append(errs, problem)
+			}
+			writeDiagnostics(format, sesameinternal.NewDiagnostics(errs...))
+		} else {
+			for _, problem := range problems {
+				logDiagnostic(slog.LevelWarn, sesameinternal.NewDiagnostics(problem)[0])
+			}
+		}
+		if len(problems) != 0 {
+			sesameinternal.Logger.Error(fmt.Sprintf("%d problem(s) found", len(problems)), "problems", len(problems))
 			os.Exit(1)
 		}
 	case "-h":
 		fmt.Fprint(os.Stderr, `sesame [COMMAND|-h]
   COMMANDS:
     generate: generates mappers(default)
+    check:    finds unused and dead entries in configurations
   OPTIONS:
     -h: show this help
 `)
@@ -71,3 +109,99 @@ redo:
 		goto redo
 	}
 }
+
+// commonFlags is flags shared by subcommands.
+type commonFlags struct {
+	Config    string
+	Help      bool
+	Quiet     bool
+	Format    string
+	Verbose   bool
+	LogFormat string
+}
+
+// newCommonFlags registers flags shared by subcommands to fs.
+func newCommonFlags(fs *flag.FlagSet) *commonFlags {
+	f := &commonFlags{}
+	fs.StringVar(&f.Config, "c", "sesame.yml", "config file path")
+	fs.BoolVar(&f.Help, "h", false, "show this help")
+	fs.BoolVar(&f.Quiet, "q", false, "suppress messages")
+	fs.StringVar(&f.Format, "format", "text", "diagnostics format: text, json or sarif")
+	fs.BoolVar(&f.Verbose, "v", false, "show debug messages")
+	fs.StringVar(&f.LogFormat, "log-format", "text", "log format: text or json")
+	return f
+}
+
+// setLogLevel sets a log level. quietLevel is used if quiet is true.
+func setLogLevel(quiet bool, quietLevel slog.Level, verbose bool) {
+	switch {
+	case verbose:
+		sesameinternal.LogLevel.Set(slog.LevelDebug)
+	case quiet:
+		sesameinternal.LogLevel.Set(quietLevel)
+	}
+}
+
+// jsonLogs is true if logs are written as JSON.
+var jsonLogs bool
+
+// parseFormat parses a diagnostics format and a log format. Logs are written
+// to stderr if the format is a machine-readable one, so that stdout contains
+// only diagnostics.
+func parseFormat(s string, logFormat string) sesameinternal.DiagnosticFormat {
+	lformat, err := sesameinternal.ParseLogFormat(logFormat)
+	if err != nil {
+		sesameinternal.Logger.Error(err.Error())
+		os.Exit(1)
+	}
+	format, err := sesameinternal.ParseDiagnosticFormat(s)
+	if err != nil {
+		sesameinternal.Logger.Error(err.Error())
+		os.Exit(1)
+	}
+	jsonLogs = lformat == sesameinternal.LogFormatJSON
+	sesameinternal.SetLogFormat(lformat, format != sesameinternal.DiagnosticFormatText)
+	return format
+}
+
+// logDiagnostic logs a diagnostic. Text logs keep a 'file:line:column:' prefix,
+// so that editors can jump to the location.
+func logDiagnostic(level slog.Level, d *sesameinternal.Diagnostic) {
+	ctx := context.Background()
+	if jsonLogs {
+		attrs := []any{"code", d.Code}
+		if d.ConfigLocation != nil {
+			attrs = append(attrs, "file", d.ConfigLocation.File,
+				"line", d.ConfigLocation.Line, "column", d.ConfigLocation.Column)
+		}
+		sesameinternal.Logger.Log(ctx, level, d.Message, attrs...)
+		return
+	}
+	if d.ConfigLocation != nil {
+		sesameinternal.Logger.Log(ctx, level, fmt.Sprintf("%s:%d:%d:\t%s",
+			d.ConfigLocation.File, d.ConfigLocation.Line, d.ConfigLocation.Column, d.Message))
+		return
+	}
+	sesameinternal.Logger.Log(ctx, level, d.Message)
+}
+
+func writeDiagnostics(format sesameinternal.DiagnosticFormat, diags []*sesameinternal.Diagnostic) {
+	if format == sesameinternal.DiagnosticFormatText {
+		return
+	}
+	if err := sesameinternal.WriteDiagnostics(os.Stdout, format, diags); err != nil {
+		sesameinternal.Logger.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+func exitWithDiagnostics(format sesameinternal.DiagnosticFormat, diags []*sesameinternal.Diagnostic) {
+	if format == sesameinternal.DiagnosticFormatText {
+		for _, d := range diags {
+			logDiagnostic(slog.LevelError, d)
+		}
+	} else {
+		writeDiagnostics(format, diags)
+	}
+	os.Exit(1)
+}
@@ -0,0 +1,203 @@
+package sesame
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ObjectKind is a kind of objects in mappers.
+type ObjectKind string
+
+const (
+	// KindMapper is a kind of mappers.
+	KindMapper ObjectKind = "mapper"
+
+	// KindConverter is a kind of converters.
+	KindConverter ObjectKind = "converter"
+
+	// KindHelper is a kind of mapper helpers.
+	KindHelper ObjectKind = "helper"
+
+	// KindFunction is a kind of functions added by [Mappers.AddFunc] .
+	KindFunction ObjectKind = "function"
+
+	// KindOther is a kind of other objects.
+	KindOther ObjectKind = "other"
+)
+
+// ObjectDescription describes an object in mappers.
+type ObjectDescription struct {
+	// ID is an id of the object.
+	ID string `json:"id"`
+
+	// Kind is a kind of the object.
+	Kind ObjectKind `json:"kind"`
+
+	// Type is a Go type of the object. Type is empty if the object
+	// is built by a factory and is not created yet.
+	Type string `json:"type,omitempty"`
+
+	// Factory is true if the object is built by a factory.
+	Factory bool `json:"factory"`
+
+	// Global is true if functions of the object are registered as
+	// global functions.
+	Global bool `json:"global"`
+}
+
+// FunctionDescription describes a mapper/converter function in mappers.
+type FunctionDescription struct {
+	// SourceType is a source type name of the function like 'time#Time'.
+	SourceType string `json:"sourceType"`
+
+	// DestType is a destination type name of the function like 'string'.
+	DestType string `json:"destType"`
+
+	// ObjectID is an id of the object that defines the function.
+	ObjectID string `json:"objectId"`
+
+	// Method is a method name of the function. Method is empty if
+	// the function is added by [Mappers.AddFunc] .
+	Method string `json:"method,omitempty"`
+
+	// Global is true if the function is registered as a global function.
+	Global bool `json:"global"`
+
+	// Active is true if the function is returned for the global lookup of
+	// SourceType -> DestType .
+	Active bool `json:"active"`
+}
+
+// Description describes objects and functions in mappers.
+type Description struct {
+	Objects   []ObjectDescription   `json:"objects"`
+	Functions []FunctionDescription `json:"functions"`
+}
+
+// FunctionsFor returns functions whose type names contain given source and
+// dest. An empty string matches any type.
+func (d *Description) FunctionsFor(source, dest string) []FunctionDescription {
+	ret := []FunctionDescription{}
+	for _, f := range d.Functions {
+		if strings.Contains(f.SourceType, source) && strings.Contains(f.DestType, dest) {
+			ret = append(ret, f)
+		}
+	}
+	return ret
+}
+
+// String returns a human readable description.
+func (d *Description) String() string {
+	var b strings.Builder
+	for _, o := range d.Objects {
+		fmt.Fprintf(&b, "%s\t%s\t%s", o.ID, o.Kind, o.Type)
+		if o.Factory {
+			b.WriteString("\tfactory")
+		}
+		if o.Global {
+			b.WriteString("\tglobal")
+		}
+		b.WriteString("\n")
+	}
+	for _, f := range d.Functions {
+		fmt.Fprintf(&b, "%s -> %s\t%s", f.SourceType, f.DestType, f.ObjectID)
+		if len(f.Method) != 0 {
+			b.WriteString("." + f.Method)
+		}
+		if f.Active {
+			b.WriteString("\tactive")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func (d *mappers) Describe() *Description {
+	desc := &Description{
+		Objects:   []ObjectDescription{},
+		Functions: []FunctionDescription{},
+	}
+
+	indexes := map[string]struct{}{}
+	for p := d; p != nil; p = p.parent {
+		p.findex.Range(func(k, _ any) bool {
+			indexes[k.(string)] = struct{}{}
+			return true
+		})
+	}
+	globals := map[string]bool{}
+	plains := map[string]bool{}
+	for index := range indexes {
+		lst := d.lookupFuncs(index)
+		active := -1
+		for i := len(lst) - 1; i >= 0; i-- {
+			if lst[i].Global {
+				active = i
+				break
+			}
+		}
+		for i := len(lst) - 1; i >= 0; i-- {
+			f := lst[i]
+			globals[f.AddedBy] = globals[f.AddedBy] || f.Global
+			plains[f.AddedBy] = plains[f.AddedBy] || f.Plain
+			desc.Functions = append(desc.Functions, FunctionDescription{
+				SourceType: TypeName(f.SourceType),
+				DestType:   TypeName(f.DestType),
+				ObjectID:   f.ObjectID,
+				Method:     f.Func.Name,
+				Global:     f.Global,
+				Active:     i == active,
+			})
+		}
+	}
+	sort.SliceStable(desc.Functions, func(i, j int) bool {
+		if desc.Functions[i].SourceType != desc.Functions[j].SourceType {
+			return desc.Functions[i].SourceType < desc.Functions[j].SourceType
+		}
+		return desc.Functions[i].DestType < desc.Functions[j].DestType
+	})
+
+	seen := map[string]bool{}
+	for p := d; p != nil; p = p.parent {
+		describe := func(id string, factory bool) bool {
+			if seen[id] {
+				return true
+			}
+			seen[id] = true
+			o := ObjectDescription{
+				ID:      id,
+				Kind:    objectKind(id, plains[id]),
+				Factory: factory,
+				Global:  globals[id],
+			}
+			if v, ok := p.dependencies.Load(id); ok && v != nil {
+				o.Type = reflect.TypeOf(v).String()
+			}
+			desc.Objects = append(desc.Objects, o)
+			return true
+		}
+		// Objects created by factories are stored in dependencies too.
+		p.factories.Range(func(k, _ any) bool { return describe(k.(string), true) })
+		p.dependencies.Range(func(k, _ any) bool { return describe(k.(string), false) })
+	}
+	sort.Slice(desc.Objects, func(i, j int) bool { return desc.Objects[i].ID < desc.Objects[j].ID })
+	return desc
+}
+
+func objectKind(id string, plain bool) ObjectKind {
+	if plain {
+		return KindFunction
+	}
+	name := trimVersionSuffix(id)
+	switch {
+	case strings.HasSuffix(name, "Mapper"):
+		return KindMapper
+	case strings.HasSuffix(name, "Converter"):
+		return KindConverter
+	case strings.HasSuffix(name, "Helper"):
+		return KindHelper
+	}
+	return KindOther
+}
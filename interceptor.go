@@ -0,0 +1,96 @@
+package sesame
+
+import (
+	"context"
+	"reflect"
+)
+
+// CallInfo describes a call of a mapper/converter function.
+type CallInfo struct {
+	// ObjectID is an id of the object that defines the function.
+	ObjectID string
+
+	// Method is a method name of the function. Method is empty if
+	// the function is added by [Mappers.AddFunc] .
+	Method string
+
+	// SourceType is a source type name of the function like 'time#Time'.
+	SourceType string
+
+	// DestType is a destination type name of the function like 'string'.
+	DestType string
+
+	// Kind is [KindMapper] for mapper functions and [KindConverter] for
+	// converter functions.
+	Kind ObjectKind
+}
+
+// Interceptor is a function that wraps calls of mapper/converter functions.
+// An interceptor must call next to call the function, and may return
+// an error other than the one that next returns.
+type Interceptor func(ctx context.Context, info CallInfo, next func() error) error
+
+// WithInterceptor adds an interceptor that wraps every function returned by
+// [MapperGetter.GetFunc] and [MapperGetter.GetFuncByTypeName] , and
+// therefore every call that generated mappers make into other mappers and
+// converters. Interceptors are called in the order they are added.
+//
+//	mappers := sesame.NewMappers(sesame.WithInterceptor(
+//	    func(ctx context.Context, info sesame.CallInfo, next func() error) error {
+//	        start := time.Now()
+//	        defer func() { log.Println(info.ObjectID, info.Method, time.Since(start)) }()
+//	        return next()
+//	    }))
+func WithInterceptor(interceptor Interceptor) MappersOption {
+	return func(o *mappersOptions) {
+		o.Interceptors = append(o.Interceptors, interceptor)
+	}
+}
+
+// intercept wraps fn with interceptors.
+func (d *mappers) intercept(fn any, f mfunc) any {
+	if len(d.interceptors) == 0 {
+		return fn
+	}
+	info := CallInfo{
+		ObjectID:   f.ObjectID,
+		Method:     f.Func.Name,
+		SourceType: TypeName(f.SourceType),
+		DestType:   TypeName(f.DestType),
+		Kind:       KindConverter,
+	}
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.NumOut() == 1 {
+		info.Kind = KindMapper
+	}
+	interceptors := d.interceptors
+	return reflect.MakeFunc(ft, func(args []reflect.Value) []reflect.Value {
+		ctx, _ := args[0].Interface().(context.Context)
+		var results []reflect.Value
+		call := func() error {
+			results = fv.Call(args)
+			err, _ := results[len(results)-1].Interface().(error)
+			return err
+		}
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], call
+			call = func() error {
+				return interceptor(ctx, info, next)
+			}
+		}
+		err := call()
+		if results == nil {
+			results = make([]reflect.Value, ft.NumOut())
+			for i := range results {
+				results[i] = reflect.Zero(ft.Out(i))
+			}
+		}
+		errValue := reflect.Zero(errorType)
+		if err != nil {
+			errValue = reflect.ValueOf(&err).Elem()
+		}
+		results[len(results)-1] = errValue
+		return results
+	}).Interface()
+}
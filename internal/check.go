@@ -0,0 +1,191 @@
+package internal
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Checker is an interface that finds unused and dead entries in
+// configurations.
+type Checker interface {
+	// Check returns problems found in configurations.
+	Check() ([]*ConfigError, error)
+}
+
+type checker struct {
+	config      *Generation
+	stringLits  map[string]map[string]struct{}
+	destination map[string]*Mapping
+}
+
+// NewChecker creates a new [Checker] .
+func NewChecker(config *Generation) Checker {
+	return &checker{
+		config:      config,
+		stringLits:  map[string]map[string]struct{}{},
+		destination: map[string]*Mapping{},
+	}
+}
+
+func (c *checker) Check() ([]*ConfigError, error) {
+	var problems []*ConfigError
+	for _, mapping := range c.config.Mappings {
+		Logger.Info("Check", "mapping", mapping.Name)
+		ps, err := c.checkMapping(mapping)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, ps...)
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		pi, pj := problems[i].Position, problems[j].Position
+		if pi.File != pj.File {
+			return pi.File < pj.File
+		}
+		return pi.Line < pj.Line
+	})
+	return problems, nil
+}
+
+func (c *checker) checkMapping(mapping *Mapping) ([]*ConfigError, error) {
+	var problems []*ConfigError
+	add := func(code string, pos Position, format string, args ...any) *ConfigError {
+		problem := configErrorf(code, pos, format, args...)
+		problem.Severity = SeverityWarning
+		problems = append(problems, problem)
+		return problem
+	}
+
+	if other, ok := c.destination[mapping.Destination]; ok && other.Package != mapping.Package {
+		add(CodeMultiplePackages, mapping.SourcePositions.Of("package"),
+			"Destination %s collects mappers from different packages: %s(%s) and %s(%s)",
+			mapping.Destination, other.Package, other.Name, mapping.Package, mapping.Name)
+	} else if !ok {
+		c.destination[mapping.Destination] = mapping
+	}
+
+	mctx := NewMappingContext("")
+	a, b, err := parseOperands(mapping, mctx)
+	if err != nil {
+		return nil, err
+	}
+	operands := map[OperandType]types.Object{
+		OperandA: a,
+		OperandB: b,
+	}
+
+	for _, fm := range mapping.Ignores {
+		for typ, obj := range operands {
+			name := fm.Value(typ)
+			if len(name) == 0 {
+				continue
+			}
+			if owner, err := validateMemberPath(obj, name, mapping.IgnoreCase); err != nil {
+				problem := add(CodeUnusedIgnore, fm.SourcePositions.Of(strings.ToLower(typ.String())),
+					"Ignored field no longer exists: %s", err)
+				problem.GoPosition = GoPositionOf(owner)
+			}
+		}
+	}
+
+	for _, fm := range mapping.Fields {
+		if len(fm.Uses) != 0 || len(fm.UsesForElements) != 0 || mapping.ExplicitOnly {
+			continue
+		}
+		if fm.A == "*" || fm.B == "*" || strings.Contains(fm.A, ".") || strings.Contains(fm.B, ".") {
+			continue
+		}
+		if fm.A != fm.B && !(mapping.IgnoreCase && strings.EqualFold(fm.A, fm.B)) {
+			continue
+		}
+		if _, err := validateMemberPath(a, fm.A, mapping.IgnoreCase); err != nil {
+			continue
+		}
+		if _, err := validateMemberPath(b, fm.B, mapping.IgnoreCase); err != nil {
+			continue
+		}
+		add(CodeRedundantField, fm.SourcePositions.Of(""),
+			"Field mapping '%s' to '%s' is identical to an implicit mapping", fm.A, fm.B)
+	}
+
+	// Statically wired objects are referenced by generated code.
+	if mapping.Wiring == WiringStatic {
+		return problems, nil
+	}
+
+	rootPath, err := findRootPath(mapping.SourceFile)
+	if err != nil {
+		return nil, err
+	}
+	lits, err := c.collectStringLiterals(rootPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, fm := range mapping.Fields {
+		for _, key := range []string{"uses", "uses-for-elements"} {
+			id := fm.Uses
+			if key == "uses-for-elements" {
+				id = fm.UsesForElements
+			}
+			if len(id) == 0 {
+				continue
+			}
+			if _, ok := lits[id]; !ok {
+				add(CodeUnreferencedConverter, fm.SourcePositions.Of(key), "Converter %s is referenced nowhere in code", id)
+			}
+		}
+	}
+	return problems, nil
+}
+
+// collectStringLiterals collects all string literals in hand-written Go
+// source files under the given module root.
+func (c *checker) collectStringLiterals(rootPath string) (map[string]struct{}, error) {
+	if lits, ok := c.stringLits[rootPath]; ok {
+		return lits, nil
+	}
+	lits := map[string]struct{}{}
+	fset := token.NewFileSet()
+	err := filepath.WalkDir(rootPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != rootPath && (strings.HasPrefix(d.Name(), ".") || d.Name() == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		f, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil
+		}
+		if ast.IsGenerated(f) {
+			return nil
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				if v, err := strconv.Unquote(lit.Value); err == nil {
+					lits[v] = struct{}{}
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.stringLits[rootPath] = lits
+	return lits, nil
+}
@@ -89,11 +89,18 @@ func loadMap(path string, fs fs.FS) (m map[any]any, err error) {
 		return nil, err
 	}
 
-	tm := map[any]any{}
-	err = yaml.Unmarshal(data, &tm)
+	var node yaml.Node
+	err = yaml.Unmarshal(data, &node)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to unmarshal a YAML file '%s': %w", path, err)
 	}
+	tm := map[any]any{}
+	if node.Kind != 0 {
+		if err := node.Decode(&tm); err != nil {
+			return nil, fmt.Errorf("Failed to unmarshal a YAML file '%s': %w", path, err)
+		}
+		injectPositions(&node, tm, path)
+	}
 
 	var files []string
 	includes, ok := tm["_includes"]
@@ -128,6 +135,131 @@ func loadMap(path string, fs fs.FS) (m map[any]any, err error) {
 
 var sourceFileKey = reflect.ValueOf("sourceFile")
 
+const sourcePositionsKey = "sourcePositions"
+
+// Position is a position of a node in configuration files.
+type Position struct {
+	// File is a path of the configuration file.
+	File string
+
+	// Line is a line number starting at 1.
+	Line int
+
+	// Column is a column number starting at 1.
+	Column int
+}
+
+// IsValid returns true if this position points to a file.
+func (p Position) IsValid() bool {
+	return len(p.File) != 0
+}
+
+// String implements [fmt].Stringer.
+// Positions are formatted as 'file:line:col'.
+func (p Position) String() string {
+	if p.Line == 0 {
+		return p.File
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// Positions is a collection of positions of a YAML mapping node and its keys.
+// An empty key holds a position of the mapping node itself.
+type Positions map[string]Position
+
+// Of returns a position of the given key.
+// If the key does not exist, Of returns a position of the mapping node itself.
+func (p Positions) Of(key string) Position {
+	if v, ok := p[key]; ok {
+		return v
+	}
+	return p[""]
+}
+
+// ConfigError is an error related to a node in configuration files.
+type ConfigError struct {
+	// Severity is a severity of this error.
+	Severity Severity
+
+	// Code is an identifier of the kind of this error.
+	Code string
+
+	// Position is a position of the node that causes this error.
+	Position Position
+
+	// GoPosition is a position of the Go type that relates to this error.
+	// GoPosition may be invalid.
+	GoPosition Position
+
+	// Message is a description of this error.
+	Message string
+}
+
+// Error implements error.
+func (e *ConfigError) Error() string {
+	if !e.Position.IsValid() {
+		return e.Message
+	}
+	return e.Position.String() + ":\t" + e.Message
+}
+
+func configErrorf(code string, pos Position, format string, args ...any) *ConfigError {
+	return &ConfigError{
+		Severity: SeverityError,
+		Code:     code,
+		Position: pos,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+func newPositionValue(path string, node *yaml.Node) map[string]any {
+	return map[string]any{
+		"file":   path,
+		"line":   node.Line,
+		"column": node.Column,
+	}
+}
+
+// injectPositions adds positions of YAML mapping nodes to decoded maps,
+// so that every configuration struct knows where it is defined.
+func injectPositions(node *yaml.Node, v any, path string) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) != 0 {
+			injectPositions(node.Content[0], v, path)
+		}
+	case yaml.AliasNode:
+		injectPositions(node.Alias, v, path)
+	case yaml.SequenceNode:
+		lst, ok := v.([]any)
+		if !ok {
+			return
+		}
+		for i, child := range node.Content {
+			if i < len(lst) {
+				injectPositions(child, lst[i], path)
+			}
+		}
+	case yaml.MappingNode:
+		m := reflect.ValueOf(v)
+		if m.Kind() != reflect.Map {
+			return
+		}
+		positions := map[string]any{
+			"": newPositionValue(path, node),
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			positions[key.Value] = newPositionValue(path, key)
+			if child := m.MapIndex(reflect.ValueOf(key.Value)); child.IsValid() {
+				injectPositions(node.Content[i+1], child.Interface(), path)
+			}
+		}
+		m.SetMapIndex(reflect.ValueOf(sourcePositionsKey), reflect.ValueOf(positions))
+	default:
+	}
+}
+
 func expandEnvVars(v reflect.Value, path string) {
 	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
 		v = v.Elem()
@@ -145,6 +277,9 @@ func expandEnvVars(v reflect.Value, path string) {
 		}
 	case reflect.Map:
 		for _, k := range v.MapKeys() {
+			if fmt.Sprint(k.Interface()) == sourcePositionsKey {
+				continue
+			}
 			child := v.MapIndex(k)
 			if child.Elem().Kind() == reflect.String {
 				s := os.Expand(child.Elem().String(), envMapper)
@@ -192,6 +327,7 @@ func walkConfig(v reflect.Value, path string) []error {
 			}
 			if child.Kind() == reflect.Struct {
 				if f := child.FieldByName("SourceFile"); f.IsValid() && f.String() != prevSourceFile {
+					prevSourceFile = f.String()
 					offset = i
 				}
 			}
@@ -19,6 +19,32 @@ type MappingContext struct {
 	mapperFuncCount     int
 	converterFuncFields []*ConverterFuncField
 	converterFuncCount  int
+	currentMapping      string
+	location            Position
+	strict              bool
+	staticDeps          map[string][]*StaticDependency
+	requirements        map[string][]*Requirement
+}
+
+// Requirement is an object that a generated mapper needs at runtime.
+type Requirement struct {
+	// ObjectID is an object id of the required object.
+	// If a global function is required, ObjectID is an empty string.
+	ObjectID string
+
+	// Source is a source type of the required function.
+	// Source is nil if the requirement is not a function.
+	Source types.Type
+
+	// Dest is a dest type of the required function.
+	// Dest is nil if the requirement is not a function.
+	Dest types.Type
+
+	// Optional is true if the generated mapper works without the object.
+	Optional bool
+
+	// Location is a position of the configuration that needs the object.
+	Location Position
 }
 
 // MapperFuncField is a mapper function field.
@@ -93,6 +119,8 @@ func NewMappingContext(absPkgPath string) *MappingContext {
 		mapperFuncCount:     0,
 		converterFuncFields: []*ConverterFuncField{},
 		converterFuncCount:  0,
+		staticDeps:          map[string][]*StaticDependency{},
+		requirements:        map[string][]*Requirement{},
 	}
 	mctx.AddImport("context")
 	return mctx
@@ -247,6 +275,107 @@ func (c *MappingContext) ConverterFuncFields() []*ConverterFuncField {
 	return c.converterFuncFields
 }
 
+// SetCurrentMapping sets a name of the mapping that is being generated.
+func (c *MappingContext) SetCurrentMapping(name string) {
+	c.currentMapping = name
+	c.location = Position{}
+	c.strict = false
+}
+
+// SetLocation sets a position of the configuration that is being generated.
+func (c *MappingContext) SetLocation(pos Position) {
+	c.location = pos
+}
+
+// Location returns a position of the configuration that is being generated.
+func (c *MappingContext) Location() Position {
+	return c.location
+}
+
+// CurrentMapping returns a name of the mapping that is being generated.
+func (c *MappingContext) CurrentMapping() string {
+	return c.currentMapping
+}
+
+// SetStrict sets whether the mapping that is being generated is strict.
+func (c *MappingContext) SetStrict(v bool) {
+	c.strict = v
+}
+
+// Strict returns true if the mapping that is being generated is strict.
+func (c *MappingContext) Strict() bool {
+	return c.strict
+}
+
+// AddRequirement adds an object that the current mapping needs at runtime.
+// If same requirement already exists, AddRequirement merges them.
+func (c *MappingContext) AddRequirement(objectID string, sourceType types.Type, destType types.Type,
+	optional bool) {
+	for _, r := range c.requirements[c.currentMapping] {
+		if r.ObjectID == objectID && sameTypeName(r.Source, sourceType) && sameTypeName(r.Dest, destType) {
+			if r.Optional && !optional {
+				r.Optional = false
+				r.Location = c.location
+			}
+			return
+		}
+	}
+	c.requirements[c.currentMapping] = append(c.requirements[c.currentMapping], &Requirement{
+		ObjectID: objectID,
+		Source:   sourceType,
+		Dest:     destType,
+		Optional: optional,
+		Location: c.location,
+	})
+}
+
+// Requirements returns a list of [Requirement] of the given mapping.
+func (c *MappingContext) Requirements(name string) []*Requirement {
+	return c.requirements[name]
+}
+
+// AddStaticDependencies adds objects that are bound to the given mapping
+// at compile time.
+func (c *MappingContext) AddStaticDependencies(name string, deps []*StaticDependency) {
+	c.staticDeps[name] = append(c.staticDeps[name], deps...)
+}
+
+// StaticDependencies returns a list of [StaticDependency] of the given mapping.
+func (c *MappingContext) StaticDependencies(name string) []*StaticDependency {
+	return c.staticDeps[name]
+}
+
+// StaticDependency returns a [StaticDependency] of the current mapping
+// that has the given reference. If it is not found, StaticDependency returns nil.
+func (c *MappingContext) StaticDependency(ref string) *StaticDependency {
+	for _, dep := range c.staticDeps[c.currentMapping] {
+		if dep.Ref == ref {
+			return dep
+		}
+	}
+	return nil
+}
+
+// IsStaticRef returns true if the given reference is bound at compile time
+// by any mapping.
+func (c *MappingContext) IsStaticRef(ref string) bool {
+	for _, deps := range c.staticDeps {
+		for _, dep := range deps {
+			if dep.Ref == ref {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func sameTypeName(t1, t2 types.Type) bool {
+	if t1 == nil || t2 == nil {
+		return t1 == t2
+	}
+	return GetQualifiedTypeName(t1) == GetQualifiedTypeName(t2)
+}
+
 func funcObjectName(sourceType, destType types.Type, fid FuncID) string {
 	if string(fid) != "" {
 		return string(fid)
@@ -0,0 +1,324 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Severity is a severity of diagnostics.
+type Severity string
+
+const (
+	// SeverityError is a severity for errors that prevent generation.
+	SeverityError Severity = "error"
+
+	// SeverityWarning is a severity for problems that do not prevent
+	// generation.
+	SeverityWarning Severity = "warning"
+)
+
+// Codes of diagnostics.
+const (
+	// CodeError is a code for errors that are not categorized.
+	CodeError = "error"
+
+	// CodeInvalidConfig is a code for malformed configurations.
+	CodeInvalidConfig = "invalid-config"
+
+	// CodeDuplicatedName is a code for duplicated mapping names.
+	CodeDuplicatedName = "duplicated-name"
+
+	// CodeStructNotFound is a code for structs that can not be loaded.
+	CodeStructNotFound = "struct-not-found"
+
+	// CodeUnknownField is a code for references to fields that do not exist.
+	CodeUnknownField = "unknown-field"
+
+	// CodeUnmappedField is a code for fields that are not mapped.
+	CodeUnmappedField = "unmapped-field"
+
+	// CodeUnmappableField is a code for fields that can not be mapped.
+	CodeUnmappableField = "unmappable-field"
+
+	// CodeTypeMismatch is a code for fields that have incompatible types.
+	CodeTypeMismatch = "type-mismatch"
+
+	// CodeUnresolvedFunction is a code for statically wired objects that
+	// have no method for a mapping.
+	CodeUnresolvedFunction = "unresolved-function"
+
+	// CodeMultiplePackages is a code for destinations that collect mappers
+	// from different packages.
+	CodeMultiplePackages = "multiple-packages"
+
+	// CodeUnusedIgnore is a code for ignores that name fields that no longer
+	// exist.
+	CodeUnusedIgnore = "unused-ignore"
+
+	// CodeRedundantField is a code for field mappings that are identical to
+	// implicit mappings.
+	CodeRedundantField = "redundant-field"
+
+	// CodeUnreferencedConverter is a code for converters that are referenced
+	// nowhere in code.
+	CodeUnreferencedConverter = "unreferenced-converter"
+)
+
+var codeDescriptions = map[string]string{
+	CodeError:                 "Error",
+	CodeInvalidConfig:         "Configuration is malformed",
+	CodeDuplicatedName:        "Mapping name is duplicated",
+	CodeStructNotFound:        "Struct can not be loaded",
+	CodeUnknownField:          "Referenced field does not exist",
+	CodeUnmappedField:         "Field is not mapped",
+	CodeUnmappableField:       "Field can not be mapped",
+	CodeTypeMismatch:          "Field types are incompatible",
+	CodeUnresolvedFunction:    "Statically wired object has no method for a mapping",
+	CodeMultiplePackages:      "Destination collects mappers from different packages",
+	CodeUnusedIgnore:          "Ignored field no longer exists",
+	CodeRedundantField:        "Field mapping is identical to an implicit mapping",
+	CodeUnreferencedConverter: "Converter is referenced nowhere in code",
+}
+
+// DiagnosticFormat is a format of diagnostics output.
+type DiagnosticFormat string
+
+const (
+	// DiagnosticFormatText is a human readable format.
+	DiagnosticFormatText DiagnosticFormat = "text"
+
+	// DiagnosticFormatJSON is a JSON format.
+	DiagnosticFormatJSON DiagnosticFormat = "json"
+
+	// DiagnosticFormatSARIF is a SARIF 2.1.0 format.
+	DiagnosticFormatSARIF DiagnosticFormat = "sarif"
+)
+
+// ParseDiagnosticFormat parses a given string as a [DiagnosticFormat] .
+func ParseDiagnosticFormat(s string) (DiagnosticFormat, error) {
+	switch f := DiagnosticFormat(strings.ToLower(s)); f {
+	case DiagnosticFormatText, DiagnosticFormatJSON, DiagnosticFormatSARIF:
+		return f, nil
+	}
+	return "", fmt.Errorf("Unknown format: %s(must be one of text, json or sarif)", s)
+}
+
+// Location is a location in source files.
+type Location struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+func newLocation(pos Position) *Location {
+	if !pos.IsValid() {
+		return nil
+	}
+	return &Location{File: pos.File, Line: pos.Line, Column: pos.Column}
+}
+
+// Diagnostic is a structured representation of an error or a problem.
+type Diagnostic struct {
+	Severity       Severity  `json:"severity"`
+	Code           string    `json:"code"`
+	Message        string    `json:"message"`
+	ConfigLocation *Location `json:"configLocation,omitempty"`
+	GoLocation     *Location `json:"goLocation,omitempty"`
+}
+
+// NewDiagnostics converts given errors into diagnostics.
+// Errors joined by errors.Join are flattened.
+func NewDiagnostics(errs ...error) []*Diagnostic {
+	var diags []*Diagnostic
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			diags = append(diags, NewDiagnostics(joined.Unwrap()...)...)
+			continue
+		}
+		var cerr *ConfigError
+		if errors.As(err, &cerr) {
+			diags = append(diags, &Diagnostic{
+				Severity:       cerr.Severity,
+				Code:           cerr.Code,
+				Message:        cerr.Message,
+				ConfigLocation: newLocation(cerr.Position),
+				GoLocation:     newLocation(cerr.GoPosition),
+			})
+			continue
+		}
+		diags = append(diags, &Diagnostic{
+			Severity: SeverityError,
+			Code:     CodeError,
+			Message:  err.Error(),
+		})
+	}
+	return diags
+}
+
+// WriteDiagnostics writes given diagnostics to w in the given format.
+func WriteDiagnostics(w io.Writer, format DiagnosticFormat, diags []*Diagnostic) error {
+	switch format {
+	case DiagnosticFormatJSON:
+		if diags == nil {
+			diags = []*Diagnostic{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diags)
+	case DiagnosticFormatSARIF:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(newSARIFLog(diags))
+	default:
+		for _, d := range diags {
+			if d.ConfigLocation != nil {
+				if _, err := fmt.Fprintf(w, "%s:%d:%d:\t%s\n",
+					d.ConfigLocation.File, d.ConfigLocation.Line, d.ConfigLocation.Column, d.Message); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := fmt.Fprintln(w, d.Message); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID           string          `json:"ruleId"`
+	Level            string          `json:"level"`
+	Message          sarifMessage    `json:"message"`
+	Locations        []sarifLocation `json:"locations,omitempty"`
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+func newSARIFLog(diags []*Diagnostic) *sarifLog {
+	codes := map[string]struct{}{}
+	results := []sarifResult{}
+	for _, d := range diags {
+		codes[d.Code] = struct{}{}
+		r := sarifResult{
+			RuleID:  d.Code,
+			Level:   string(d.Severity),
+			Message: sarifMessage{Text: d.Message},
+		}
+		if d.ConfigLocation != nil {
+			r.Locations = append(r.Locations, newSARIFLocation(d.ConfigLocation, ""))
+		}
+		if d.GoLocation != nil {
+			r.RelatedLocations = append(r.RelatedLocations, newSARIFLocation(d.GoLocation, "Go type"))
+		}
+		results = append(results, r)
+	}
+	rules := []sarifRule{}
+	for code := range codes {
+		desc, ok := codeDescriptions[code]
+		if !ok {
+			desc = code
+		}
+		rules = append(rules, sarifRule{ID: code, ShortDescription: sarifMessage{Text: desc}})
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+
+	return &sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs: []sarifRun{
+			{
+				Tool: sarifTool{
+					Driver: sarifDriver{
+						Name:           "sesame",
+						InformationURI: "https://github.com/yuin/sesame",
+						Rules:          rules,
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+}
+
+func newSARIFLocation(loc *Location, message string) sarifLocation {
+	l := sarifLocation{
+		PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: sarifURI(loc.File)},
+			Region:           sarifRegion{StartLine: loc.Line, StartColumn: loc.Column},
+		},
+	}
+	if len(message) != 0 {
+		l.Message = &sarifMessage{Text: message}
+	}
+	return l
+}
+
+func sarifURI(path string) string {
+	if !filepath.IsAbs(path) {
+		return filepath.ToSlash(path)
+	}
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return "file://" + filepath.ToSlash(path)
+}
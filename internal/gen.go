@@ -1,9 +1,12 @@
 package internal
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"go/types"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -50,24 +53,24 @@ type Generation struct {
 
 	// SourceFile is a source file path that contains this configuration.
 	SourceFile string
+
+	// SourcePositions is positions of this configuration in the SourceFile.
+	SourcePositions Positions
 }
 
 // ConfigLoaded is an event handler will be executed when config is loaded.
 func (g *Generation) ConfigLoaded(_ string) []error {
 	var errs []error
-	names := map[string]string{}
+	names := map[string]*Mapping{}
 	msNilMap := g.Mappers.NilMap
 	msNilSlice := g.Mappers.NilSlice
 	for _, m := range g.Mappings {
-		f, ok := names[m.Name]
-		if ok {
-			msg := fmt.Sprintf(", file: %s", f)
-			if f != m.SourceFile {
-				msg = fmt.Sprintf(", files: %s %s", f, m.SourceFile)
-			}
-			errs = append(errs, fmt.Errorf("mappings.name must be an unique(duplicated name: %s%s)", m.Name, msg))
+		if f, ok := names[m.Name]; ok {
+			errs = append(errs, configErrorf(CodeDuplicatedName, m.SourcePositions.Of("name"),
+				"mappings.name must be an unique(duplicated name: %s, previously defined at %s)",
+				m.Name, f.SourcePositions.Of("name")))
 		}
-		names[m.Name] = m.SourceFile
+		names[m.Name] = m
 
 		if m.NilMap == NilCollectionUnknown {
 			m.NilMap = msNilMap
@@ -136,6 +139,9 @@ type Mappers struct {
 
 	// SourceFile is a source file path that contains this configuration.
 	SourceFile string
+
+	// SourcePositions is positions of this configuration in the SourceFile.
+	SourcePositions Positions
 }
 
 // ConfigLoaded is an event handler will be executed when config is loaded.
@@ -148,10 +154,10 @@ func (m *Mappers) ConfigLoaded(path string) []error {
 		m.NilSlice = NilCollectionAsNil
 	}
 	if len(m.Package) == 0 {
-		errs = append(errs, fmt.Errorf("%s:\t%s.package must not be empty", m.SourceFile, path))
+		errs = append(errs, configErrorf(CodeInvalidConfig, m.SourcePositions.Of("package"), "%s.package must not be empty", path))
 	}
 	if len(m.Destination) == 0 {
-		errs = append(errs, fmt.Errorf("%s:\t%s.destination must not be empty", m.SourceFile, path))
+		errs = append(errs, configErrorf(CodeInvalidConfig, m.SourcePositions.Of("destination"), "%s.destination must not be empty", path))
 	}
 	if !filepath.IsAbs(m.Destination) {
 		m.Destination = filepath.Join(filepath.Dir(m.SourceFile), m.Destination)
@@ -189,9 +195,24 @@ type Mapping struct {
 	// B is a mapping operand.
 	B *MappingOperand
 
+	// Wiring is a way to bind converters, mappers and helpers to this mapper.
+	// If this is empty, [WiringDynamic] will be used.
+	Wiring Wiring
+
+	// Helper is a type of the helper like './mapper.MyHelper' .
+	// Helper is available only with [WiringStatic] .
+	Helper string
+
+	// Instrument is set true, generated mappers report calls to an observer
+	// registered with the id 'SesameObserver'.
+	Instrument bool
+
 	// SourceFile is a source file path that contains this configuration.
 	SourceFile string
 
+	// SourcePositions is positions of this configuration in the SourceFile.
+	SourcePositions Positions
+
 	// ObjectMapping is a mapping definition for objects.
 	ObjectMapping `mapstructure:",squash"`
 }
@@ -200,10 +221,10 @@ type Mapping struct {
 func (m *Mapping) ConfigLoaded(path string) []error {
 	var errs []error
 	if len(m.Name) == 0 {
-		errs = append(errs, fmt.Errorf("%s:\t%s.name must not be empty", m.SourceFile, path))
+		errs = append(errs, configErrorf(CodeInvalidConfig, m.SourcePositions.Of("name"), "%s.name must not be empty", path))
 	}
 	if len(m.Destination) == 0 {
-		errs = append(errs, fmt.Errorf("%s:\t%s.destination must not be empty", m.SourceFile, path))
+		errs = append(errs, configErrorf(CodeInvalidConfig, m.SourcePositions.Of("destination"), "%s.destination must not be empty", path))
 	}
 	if !filepath.IsAbs(m.Destination) {
 		m.Destination = filepath.Join(filepath.Dir(m.SourceFile), m.Destination)
@@ -212,14 +233,27 @@ func (m *Mapping) ConfigLoaded(path string) []error {
 		m.Package = filepath.Base(m.Destination)
 	}
 	if m.A == nil {
-		errs = append(errs, fmt.Errorf("%s:\t%s.a must not be empty", m.SourceFile, path))
+		errs = append(errs, configErrorf(CodeInvalidConfig, m.SourcePositions.Of("a"), "%s.a must not be empty", path))
 	}
 	if m.B == nil {
-		errs = append(errs, fmt.Errorf("%s:\t%s.b must not be empty", m.SourceFile, path))
+		errs = append(errs, configErrorf(CodeInvalidConfig, m.SourcePositions.Of("b"), "%s.b must not be empty", path))
 	}
 	if len(m.ID) == 0 {
 		m.ID = m.Name
 	}
+	switch m.Wiring {
+	case "":
+		m.Wiring = WiringDynamic
+	case WiringDynamic, WiringStatic:
+	default:
+		errs = append(errs, configErrorf(CodeInvalidConfig, m.SourcePositions.Of("wiring"),
+			"%s.wiring must be one of 'dynamic' or 'static'", path))
+	}
+	if len(m.Helper) != 0 && m.Wiring != WiringStatic {
+		errs = append(errs, configErrorf(CodeInvalidConfig, m.SourcePositions.Of("helper"),
+			"%s.helper is available only with 'wiring: static'", path))
+	}
+	m.ObjectMapping.positions = m.SourcePositions
 	return errs
 }
 
@@ -256,6 +290,10 @@ type ObjectMapping struct {
 	// field exists.
 	AllowUnmapped bool `mapstructure:"allow-unmapped"`
 
+	// Strict is set true, generated mappers return an error if
+	// a required converter or mapper is not registered.
+	Strict bool
+
 	// Fields is definitions of how fields will be mapped.
 	Fields FieldMappings
 
@@ -267,6 +305,8 @@ type ObjectMapping struct {
 
 	// NilSlice defines how are nil maps are mapped.
 	NilSlice NilCollection `mapstructure:"nil-slice"`
+
+	positions Positions
 }
 
 // NewObjectMapping creates new [ObjectMapping] .
@@ -274,6 +314,16 @@ func NewObjectMapping() *ObjectMapping {
 	return &ObjectMapping{}
 }
 
+// Position returns a position of the given key of the given [FieldMapping] .
+// If the field mapping is not defined in configuration files, Position returns
+// a position of this mapping.
+func (m *ObjectMapping) Position(fm *FieldMapping, key string) Position {
+	if fm != nil && len(fm.SourcePositions) != 0 {
+		return fm.SourcePositions.Of(key)
+	}
+	return m.positions.Of("")
+}
+
 // AddField adds new [FieldMapping] to this definition.
 func (m *ObjectMapping) AddField(typ OperandType, v1, v2 string) {
 	if typ == OperandA {
@@ -300,16 +350,19 @@ type MappingOperand struct {
 
 	// SourceFile is a source file path that contains this configuration.
 	SourceFile string
+
+	// SourcePositions is positions of this configuration in the SourceFile.
+	SourcePositions Positions
 }
 
 // ConfigLoaded is an event handler will be executed when config is loaded.
 func (m *MappingOperand) ConfigLoaded(path string) []error {
 	var errs []error
 	if len(m.Package) == 0 {
-		errs = append(errs, fmt.Errorf("%s:\t%s.package must not be empty", m.SourceFile, path))
+		errs = append(errs, configErrorf(CodeInvalidConfig, m.SourcePositions.Of("package"), "%s.package must not be empty", path))
 	}
 	if len(m.Name) == 0 {
-		errs = append(errs, fmt.Errorf("%s:\t%s.name must not be empty", m.SourceFile, path))
+		errs = append(errs, configErrorf(CodeInvalidConfig, m.SourcePositions.Of("name"), "%s.name must not be empty", path))
 	}
 
 	if !isModPackage(m.Package) && !filepath.IsAbs(m.Package) {
@@ -335,6 +388,9 @@ type FieldMapping struct {
 
 	// SourceFile is a source file path that contains this configuration.
 	SourceFile string
+
+	// SourcePositions is positions of this configuration in the SourceFile.
+	SourcePositions Positions
 }
 
 // Value returns a value by [OperandType] .
@@ -396,10 +452,10 @@ func (f FieldMappings) ConfigLoaded(path string) []error {
 	var errs []error
 	for i, v := range f {
 		if len(v.A) == 0 {
-			errs = append(errs, fmt.Errorf("%s:\t%s[%d].a must not be empty", v.SourceFile, path, i))
+			errs = append(errs, configErrorf(CodeInvalidConfig, v.SourcePositions.Of("a"), "%s[%d].a must not be empty", path, i))
 		}
 		if len(v.B) == 0 {
-			errs = append(errs, fmt.Errorf("%s:\t%s[%d].b must not be empty", v.SourceFile, path, i))
+			errs = append(errs, configErrorf(CodeInvalidConfig, v.SourcePositions.Of("b"), "%s[%d].b must not be empty", path, i))
 		}
 	}
 	return errs
@@ -423,7 +479,7 @@ func (f Ignores) ConfigLoaded(path string) []error {
 	var errs []error
 	for i, v := range f {
 		if len(v.A) == 0 && len(v.B) == 0 || len(v.A) != 0 && len(v.B) != 0 {
-			errs = append(errs, fmt.Errorf("%s:\t%s[%d] must define ether a or b", v.SourceFile, path, i))
+			errs = append(errs, configErrorf(CodeInvalidConfig, v.SourcePositions.Of(""), "%s[%d] must define ether a or b", path, i))
 		}
 	}
 	return errs
@@ -692,28 +748,28 @@ func (p *printer) Close() error {
 	_, _ = p.f.WriteString(data)
 	_, err := p.f.WriteString("\n")
 	if err != nil {
-		LogFunc(LogLevelError, err.Error())
+		Logger.Error(err.Error(), "file", p.path)
 	}
 
 	err = p.f.Close()
 	if err != nil {
-		LogFunc(LogLevelError, err.Error())
+		Logger.Error(err.Error(), "file", p.path)
 		return err
 	}
 	src, err := os.ReadFile(p.path)
 	if err != nil {
-		LogFunc(LogLevelError, err.Error())
+		Logger.Error(err.Error(), "file", p.path)
 		return err
 	}
 
 	res, err := imports.Process(p.path, src, goimportsOptions)
 	if err != nil {
-		LogFunc(LogLevelError, err.Error())
+		Logger.Error(err.Error(), "file", p.path)
 		return err
 	}
 	err = os.WriteFile(p.path, res, 0755)
 	if err != nil {
-		LogFunc(LogLevelError, err.Error())
+		Logger.Error(err.Error(), "file", p.path)
 		return err
 	}
 
@@ -740,6 +796,7 @@ type mapper struct {
 	id   string
 	name string
 	pkg  string
+	deps []*StaticDependency
 }
 
 func (g *generator) Generate() error {
@@ -764,7 +821,7 @@ func (g *generator) Generate() error {
 		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
 			return err
 		}
-		LogFunc(LogLevelInfo, "Generate %s", dest)
+		Logger.Info("Generate", "destination", dest)
 		printer, err := NewPrinter(dest)
 		if err != nil {
 			return err
@@ -787,30 +844,24 @@ func (g *generator) Generate() error {
 			B       types.Object
 		}, len(mappings))
 		i := 0
+		var validationErrs []error
 		for _, mapping := range mappings {
 			err := func() error {
-				oldCwd, _ := os.Getwd()
-				rootPath, err := findRootPath(mapping.SourceFile)
-				if err != nil {
-					return err
-				}
-				_ = os.Chdir(rootPath)
-				defer func() {
-					_ = os.Chdir(oldCwd)
-				}()
-
-				LogFunc(LogLevelInfo, "Parse %s#%s", mapping.A.Package, mapping.A.Name)
-				a, err := ParseStruct(mapping.A.Package, mapping.A.Name, mctx)
+				a, b, err := parseOperands(mapping, mctx)
 				if err != nil {
 					return err
 				}
-				LogFunc(LogLevelInfo, "Parse %s#%s", mapping.B.Package, mapping.B.Name)
-				b, err := ParseStruct(mapping.B.Package, mapping.B.Name, mctx)
-				if err != nil {
-					return err
+				validationErrs = append(validationErrs, ValidateMapping(mapping, a, b)...)
+				if mapping.Wiring == WiringStatic {
+					deps, err := resolveStaticDependencies(mapping, mctx)
+					if err != nil {
+						return err
+					}
+					mctx.AddStaticDependencies(mapping.Name, deps)
 				}
 				if len(pkg) > 0 && pkg != mapping.Package {
-					return fmt.Errorf("Destination %s have multiple package names", dest)
+					return configErrorf(CodeMultiplePackages, mapping.SourcePositions.Of("package"),
+						"Destination %s have multiple package names", dest)
 				}
 				pkg = mapping.Package
 				lst[i].Mapping = mapping
@@ -823,6 +874,9 @@ func (g *generator) Generate() error {
 				return err
 			}
 		}
+		if len(validationErrs) != 0 {
+			return errors.Join(validationErrs...)
+		}
 		sort.Slice(lst, func(i, j int) bool {
 			return lst[i].Mapping.Name < lst[j].Mapping.Name
 		})
@@ -835,7 +889,17 @@ func (g *generator) Generate() error {
 		p("")
 		for _, elem := range lst {
 			mapping := elem.Mapping
-			LogFunc(LogLevelInfo, "Generate %s", mapping.Name)
+			Logger.Info("Generate", "mapping", mapping.Name)
+			mctx.SetCurrentMapping(mapping.Name)
+			for _, dep := range mctx.StaticDependencies(mapping.Name) {
+				mctx.SetLocation(dep.Position)
+				mctx.AddRequirement(dep.ObjectID, nil, nil, dep.Optional)
+			}
+			mctx.SetLocation(mapping.SourcePositions.Of(""))
+			mctx.AddRequirement(mapping.ID+"Helper", nil, nil, true)
+			if mapping.Instrument {
+				mctx.AddRequirement(observerID, nil, nil, true)
+			}
 			a := elem.A
 			b := elem.B
 			aArgSource := GetStructPointerTypeSource(a.Type(), mctx)
@@ -860,14 +924,44 @@ func (g *generator) Generate() error {
 			p("")
 			p("var _ %s = &%s{}", mapping.Name, mapping.PrivateName())
 			p("")
-			p("func New%s(mapperGetter %s) %s {", mapping.Name, mapperGetterSrc, mapping.Name)
-			p("  m := &%s{", mapping.PrivateName())
-			p("    mapperGetter: mapperGetter,")
-			p("  }")
-			p("  helper, err := m.mapperGetter.Get(\"%sHelper\")", mapping.ID)
-			p("  if err == nil {")
-			p("    m.helper = helper.(%sHelper)", mapping.Name)
-			p("  }")
+			if mapping.Wiring == WiringStatic {
+				deps := mctx.StaticDependencies(mapping.Name)
+				p("// %sDeps is objects that are bound to the %s at compile time.", mapping.Name, mapping.Name)
+				p("type %sDeps struct {", mapping.Name)
+				for _, dep := range deps {
+					p("%s %s", dep.FieldName, dep.TypeSource(mctx))
+				}
+				p("}")
+				p("")
+				p("func New%s(mapperGetter %s, deps %sDeps) %s {", mapping.Name, mapperGetterSrc, mapping.Name, mapping.Name)
+				p("  m := &%s{", mapping.PrivateName())
+				p("    mapperGetter: mapperGetter,")
+				p("  }")
+				if len(mapping.Helper) != 0 {
+					p("  if deps.Helper != nil {")
+					p("    m.helper = deps.Helper")
+					p("  }")
+				}
+				printer.AddVar("INIT_STATIC_" + mapping.PrivateName())
+			} else {
+				p("func New%s(mapperGetter %s) %s {", mapping.Name, mapperGetterSrc, mapping.Name)
+				p("  m := &%s{", mapping.PrivateName())
+				p("    mapperGetter: mapperGetter,")
+				p("  }")
+			}
+			if len(mapping.Helper) == 0 {
+				p("  helper, err := m.mapperGetter.Get(\"%sHelper\")", mapping.ID)
+				p("  if err == nil {")
+				p("    m.helper = helper.(%sHelper)", mapping.Name)
+				p("  }")
+			}
+			if mapping.Instrument {
+				p("  if observer, err := m.mapperGetter.Get(\"%s\"); err == nil {", observerID)
+				p("    if v, ok := observer.(%s); ok {", observerSrc(mctx))
+				p("      m.observer = v")
+				p("    }")
+				p("  }")
+			}
 			printer.AddVar("INIT_MAPPERS")
 			p("  return m")
 			p("}")
@@ -875,11 +969,14 @@ func (g *generator) Generate() error {
 			p("type %s struct {", mapping.PrivateName())
 			p("mapperGetter %s", mapperGetterSrc)
 			p("helper %sHelper", mapping.Name)
+			if mapping.Instrument {
+				p("observer %s", observerSrc(mctx))
+			}
 			printer.AddVar("MAPPERS")
 			p("}")
 			p("")
 
-			LogFunc(LogLevelInfo, "Generate %s#%s", mapping.Name, mapping.MethodName(OperandA))
+			Logger.Info("Generate", "mapping", mapping.Name, "method", mapping.MethodName(OperandA))
 			if err := genMapFunc(printer, mapping, a, b, OperandA, mctx); err != nil {
 				return err
 			}
@@ -887,12 +984,15 @@ func (g *generator) Generate() error {
 			p("")
 
 			if mapping.Bidirectional {
-				LogFunc(LogLevelInfo, "Generate %s#%s", mapping.Name, mapping.MethodName(OperandB))
+				Logger.Info("Generate", "mapping", mapping.Name, "method", mapping.MethodName(OperandB))
 				if err := genMapFunc(printer, mapping, b, a, OperandB, mctx); err != nil {
 					return err
 				}
 			}
 
+			p("")
+			genRequirementsFunc(printer, mapping, mctx)
+
 			absPkg, err := toAbsoluteImportPath(filepath.Dir(dest))
 			if err != nil {
 				return err
@@ -903,15 +1003,30 @@ func (g *generator) Generate() error {
 				id:   mapping.ID,
 				name: mapping.Name,
 				pkg:  absPkg,
+				deps: mctx.StaticDependencies(mapping.Name),
 			})
 
-			LogFunc(LogLevelInfo, "Generate %s: Done", mapping.Name)
+			Logger.Info("Generate: Done", "mapping", mapping.Name)
+		}
+
+		for _, elem := range lst {
+			if elem.Mapping.Wiring != WiringStatic {
+				continue
+			}
+			lines, err := genStaticInitStmts(elem.Mapping, mctx)
+			if err != nil {
+				return err
+			}
+			printer.ResolveVar("INIT_STATIC_"+elem.Mapping.PrivateName(), strings.Join(lines, "\n"))
 		}
 
 		var mapperFieldNames []string
 		var initMapperFields []string
 		for _, mf := range mctx.MapperFuncFields() {
 			mapperFieldNames = append(mapperFieldNames, fmt.Sprintf("%s %s", mf.FieldName, mf.Signature(mctx)))
+			if mctx.IsStaticRef(mf.ObjectID) {
+				continue
+			}
 			initMapperFields = append(initMapperFields,
 				fmt.Sprintf(`if obj, err := mapperGetter.GetFuncByTypeName("%s", "%s", "%s"); err == nil {`,
 					mf.ObjectID,
@@ -924,6 +1039,9 @@ func (g *generator) Generate() error {
 
 		for _, cf := range mctx.ConverterFuncFields() {
 			mapperFieldNames = append(mapperFieldNames, fmt.Sprintf("%s %s", cf.FieldName, cf.Signature(mctx)))
+			if mctx.IsStaticRef(cf.ObjectID) {
+				continue
+			}
 			initMapperFields = append(initMapperFields,
 				fmt.Sprintf(`if obj, err := mapperGetter.GetFuncByTypeName("%s", "%s", "%s"); err == nil {`,
 					cf.ObjectID,
@@ -941,18 +1059,42 @@ func (g *generator) Generate() error {
 		printer.ResolveVar("MAPPERS", strings.Join(mapperFieldNames, "\n"))
 		printer.ResolveVar("INIT_MAPPERS", strings.Join(initMapperFields, "\n"))
 		printer.ResolveVar("IMPORTS", strings.Join(imps, "\n"))
-		LogFunc(LogLevelInfo, "Generate %s: Done", dest)
+		Logger.Info("Generate: Done", "destination", dest)
 	}
 
-	LogFunc(LogLevelInfo, "Generate %s", g.config.Mappers.Destination)
+	Logger.Info("Generate", "destination", g.config.Mappers.Destination)
 	if err := genMappers(g.config.Mappers, mapperList, mappersContext); err != nil {
 		return err
 	}
-	LogFunc(LogLevelInfo, "Generate %s: Done", g.config.Mappers.Destination)
+	Logger.Info("Generate: Done", "destination", g.config.Mappers.Destination)
 
 	return nil
 }
 
+func parseOperands(mapping *Mapping, mctx *MappingContext) (types.Object, types.Object, error) {
+	oldCwd, _ := os.Getwd()
+	rootPath, err := findRootPath(mapping.SourceFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	_ = os.Chdir(rootPath)
+	defer func() {
+		_ = os.Chdir(oldCwd)
+	}()
+
+	Logger.Info("Parse", "package", mapping.A.Package, "struct", mapping.A.Name)
+	a, err := ParseStruct(mapping.A.Package, mapping.A.Name, mctx)
+	if err != nil {
+		return nil, nil, configErrorf(CodeStructNotFound, mapping.A.SourcePositions.Of("name"), "%s", err)
+	}
+	Logger.Info("Parse", "package", mapping.B.Package, "struct", mapping.B.Name)
+	b, err := ParseStruct(mapping.B.Package, mapping.B.Name, mctx)
+	if err != nil {
+		return nil, nil, configErrorf(CodeStructNotFound, mapping.B.SourcePositions.Of("name"), "%s", err)
+	}
+	return a, b, nil
+}
+
 func genMappers(mappers *Mappers, mapperList []*mapper, mctx *MappingContext) error {
 	dest := mappers.Destination
 	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
@@ -977,6 +1119,8 @@ func genMappers(mappers *Mappers, mapperList []*mapper, mctx *MappingContext) er
 	}
 
 	var ms []string
+	var ids []string
+	var accessors []string
 	slices.SortStableFunc(mapperList, func(a, b *mapper) int {
 		return strings.Compare(a.id, b.id)
 	})
@@ -987,12 +1131,49 @@ func genMappers(mappers *Mappers, mapperList []*mapper, mctx *MappingContext) er
 		}
 
 		typeName := fmt.Sprintf("%s%s", prefix, m.name)
-		ms = append(ms, fmt.Sprintf(`mappers.AddFactory("%s", reflect.TypeOf((*%s)(nil)).Elem(), func(ms sesame.MapperGetter) (any, error) {`, // nolint
-			m.id, typeName))
-		ms = append(ms, fmt.Sprintf("return %sNew%s(ms), nil", prefix, m.name))
+		idName := m.name + "ID"
+		ids = append(ids,
+			fmt.Sprintf("// %s is an id of the %s.", idName, m.name),
+			fmt.Sprintf("%s = %q", idName, m.id))
+		accessors = append(accessors,
+			fmt.Sprintf("// %s returns the %s.", m.name, m.name),
+			fmt.Sprintf("func (m *GeneratedMappers) %s() (%s, error) {", m.name, typeName),
+			fmt.Sprintf("  return sesame.Get[%s](m.Mappers, %s)", typeName, idName),
+			"}",
+			"")
+		ms = append(ms, fmt.Sprintf(`mappers.AddFactory(%s, reflect.TypeOf((*%s)(nil)).Elem(), func(ms sesame.MapperGetter) (any, error) {`, // nolint
+			idName, typeName))
+		if len(m.deps) == 0 {
+			ms = append(ms, fmt.Sprintf("return %sNew%s(ms), nil", prefix, m.name))
+		} else {
+			ms = append(ms, fmt.Sprintf("var deps %s%sDeps", prefix, m.name))
+			if slices.ContainsFunc(m.deps, func(dep *StaticDependency) bool { return !dep.Optional }) {
+				ms = append(ms, "var err error")
+			}
+			for _, dep := range m.deps {
+				if !dep.Optional {
+					// Required dependencies must be registered.
+					ms = append(ms,
+						fmt.Sprintf(`if deps.%s, err = sesame.Get[%s](ms, %q); err != nil {`,
+							dep.FieldName, dep.TypeSource(mctx), dep.ObjectID),
+						"  return nil, err",
+						"}")
+					continue
+				}
+				ms = append(ms,
+					fmt.Sprintf(`if v, err := sesame.Get[%s](ms, %q); err == nil {`, dep.TypeSource(mctx), dep.ObjectID),
+					fmt.Sprintf("  deps.%s = v", dep.FieldName),
+					fmt.Sprintf("} else if !%s.Is(err, sesame.ErrNotFound) {", mctx.GetImportAlias("errors")),
+					"  return nil, err",
+					"}")
+			}
+			ms = append(ms, fmt.Sprintf("return %sNew%s(ms, deps), nil", prefix, m.name))
+		}
 		ms = append(ms, "})")
 	}
 	printer.ResolveVar("MAPPERS", strings.Join(ms, "\n"))
+	printer.ResolveVar("MAPPER_IDS", strings.Join(ids, "\n"))
+	printer.ResolveVar("MAPPER_ACCESSORS", strings.Join(accessors, "\n"))
 
 	var imps []string
 	for impPath, impAlias := range mctx.Imports() {
@@ -1004,13 +1185,106 @@ func genMappers(mappers *Mappers, mapperList []*mapper, mctx *MappingContext) er
 	return nil
 }
 
+// genStaticInitStmts generates statements that bind methods of
+// dependencies to function fields.
+func genStaticInitStmts(mapping *Mapping, mctx *MappingContext) ([]string, error) {
+	var lines []string
+	for _, dep := range mctx.StaticDependencies(mapping.Name) {
+		found := map[string]bool{}
+		bind := func(fieldName, ref string, source, dest types.Type, isMapper bool) {
+			if ref != dep.Ref {
+				return
+			}
+			key := GetQualifiedTypeName(source) + ":" + GetQualifiedTypeName(dest)
+			if _, ok := found[key]; !ok {
+				found[key] = false
+			}
+			method, ok := dep.FindMethod(source, dest, isMapper)
+			if !ok {
+				return
+			}
+			found[key] = true
+			lines = append(lines,
+				fmt.Sprintf("if deps.%s != nil {", dep.FieldName),
+				fmt.Sprintf("  m.%s = deps.%s.%s", fieldName, dep.FieldName, method),
+				"}")
+		}
+		for _, cf := range mctx.ConverterFuncFields() {
+			bind(cf.FieldName, cf.ObjectID, cf.Source, cf.Dest, false)
+		}
+		for _, mf := range mctx.MapperFuncFields() {
+			bind(mf.FieldName, mf.ObjectID, mf.Source, mf.Dest, true)
+		}
+		keys := make([]string, 0, len(found))
+		for key, ok := range found {
+			if !ok {
+				keys = append(keys, strings.Replace(key, ":", " -> ", 1))
+			}
+		}
+		if len(keys) != 0 {
+			sort.Strings(keys)
+			cerr := configErrorf(CodeUnresolvedFunction, dep.Position,
+				"%s has no method that maps %s", dep.Ref, strings.Join(keys, ", "))
+			cerr.GoPosition = GoPositionOf(dep.Object)
+			return nil, cerr
+		}
+	}
+	return lines, nil
+}
+
+func genRequirementsFunc(printer Printer, mapping *Mapping, mctx *MappingContext) {
+	p := printer.P
+	p("// Requirements returns objects that this mapper needs at runtime.")
+	typeName := requirementSrc(mctx)
+	p("func (m *%s) Requirements() []%s {", mapping.PrivateName(), typeName)
+	p("  return []%s{", typeName)
+	for _, r := range mctx.Requirements(mapping.Name) {
+		var sourceType, destType string
+		if r.Source != nil {
+			sourceType = GetQualifiedTypeName(r.Source)
+		}
+		if r.Dest != nil {
+			destType = GetQualifiedTypeName(r.Dest)
+		}
+		p("{ObjectID: %q, SourceType: %q, DestType: %q, Optional: %t, Location: %q},",
+			r.ObjectID, sourceType, destType, r.Optional, relativeLocation(r.Location))
+	}
+	p("  }")
+	p("}")
+}
+
+// relativeLocation returns a location relative to the module root, so that
+// generated files do not depend on where the module is checked out.
+func relativeLocation(pos Position) string {
+	if !pos.IsValid() {
+		return ""
+	}
+	file := pos.File
+	if rootPath, err := findRootPath(pos.File); err == nil {
+		if rel, err := filepath.Rel(rootPath, pos.File); err == nil {
+			file = rel
+		}
+	}
+	return fmt.Sprintf("%s:%d:%d", filepath.ToSlash(file), pos.Line, pos.Column)
+}
+
 func genMapFunc(printer Printer, mapping *Mapping,
 	source types.Object, dest types.Object, typ OperandType, mctx *MappingContext) error {
 	p := printer.P
 
-	p("func (m *%s) %s(ctx %s.Context, source *%s, dest *%s) error {",
-		mapping.PrivateName(), mapping.MethodName(typ), mctx.GetImportAlias("context"),
-		GetSource(source.Type(), mctx), GetSource(dest.Type(), mctx))
+	if mapping.Instrument {
+		p("func (m *%s) %s(ctx %s.Context, source *%s, dest *%s) (err error) {",
+			mapping.PrivateName(), mapping.MethodName(typ), mctx.GetImportAlias("context"),
+			GetSource(source.Type(), mctx), GetSource(dest.Type(), mctx))
+		p("  if m.observer != nil {")
+		p("    done := m.observer.Start(ctx, \"%s\", \"%s\")", mapping.ID, mapping.MethodName(typ))
+		p("    defer func() { done(err) }()")
+		p("  }")
+	} else {
+		p("func (m *%s) %s(ctx %s.Context, source *%s, dest *%s) error {",
+			mapping.PrivateName(), mapping.MethodName(typ), mctx.GetImportAlias("context"),
+			GetSource(source.Type(), mctx), GetSource(dest.Type(), mctx))
+	}
 	if err := genMapFuncBody(printer, source, "source", dest, "dest", &mapping.ObjectMapping, typ, mctx); err != nil {
 		return err
 	}
@@ -1096,8 +1370,12 @@ func genMapFuncBody(printer Printer,
 						}
 					}
 					if !found {
-						return fmt.Errorf("Could not map a field: '%s.%s' to '%s'",
+						cerr := configErrorf(CodeUnmappableField,
+							mapping.Position(fieldMapping, strings.ToLower(typ.Inverted().String())),
+							"Could not map a field: '%s.%s' to '%s'",
 							source.Pkg().Name(), sourceValue.GetGetterSource(), destName)
+						cerr.GoPosition = GoPositionOf(destNamed.Obj())
+						return cerr
 					}
 					err := genFieldMapStmts(printer, sourceValue, destValue, mapping, fieldMapping, mctx)
 					if err != nil {
@@ -1110,10 +1388,14 @@ func genMapFuncBody(printer Printer,
 				found = found && destValue.CanSet()
 				if !found {
 					if mapping.AllowUnmapped {
-						LogFunc(LogLevelDebug, "%s.%s.%s is ignored", source.Pkg().Name(), source.Name(), sourceField.Name())
+						Logger.Debug("Map field", "mapping", mctx.CurrentMapping(),
+							"field", source.Pkg().Name()+"."+source.Name()+"."+sourceField.Name(), "decision", "ignore")
 						continue
 					}
-					return fmt.Errorf("Unmapped field: '%s.%s.%s'", source.Pkg().Name(), source.Name(), sourceField.Name())
+					cerr := configErrorf(CodeUnmappedField, mapping.Position(nil, ""),
+						"Unmapped field: '%s.%s.%s'", source.Pkg().Name(), source.Name(), sourceField.Name())
+					cerr.GoPosition = GoPositionOf(sourceField)
+					return cerr
 				}
 				mapping.AddField(typ, sourceField.Name(), sourceField.Name())
 				fieldMappings = mapping.Fields.Find(typ, sourceField.Name())
@@ -1145,7 +1427,11 @@ func genMapFuncBody(printer Printer,
 				nestMapping := NewObjectMapping()
 				nestMapping.ExplicitOnly = true
 				nestMapping.AddField(typ, parts[1], destFieldName)
+				nestMapping.Fields[0].SourceFile = fm.SourceFile
+				nestMapping.Fields[0].SourcePositions = fm.SourcePositions
 				nestMapping.IgnoreCase = mapping.IgnoreCase
+				nestMapping.Strict = mapping.Strict
+				nestMapping.positions = mapping.positions
 				err := genMapFuncBody(printer, f, sourceNameBase+"."+parts[0],
 					dest, destNameBase, nestMapping, typ, mctx)
 				if err != nil {
@@ -1166,6 +1452,8 @@ func genFieldMapStmts(printer Printer,
 	p := printer.P
 	sourceType := sourceValue.Type()
 	destType := destValue.Type()
+	mctx.SetLocation(mapping.Position(fm, ""))
+	mctx.SetStrict(mapping.Strict)
 
 	switch typ := sourceType.(type) {
 	case *types.Array:
@@ -1176,7 +1464,7 @@ func genFieldMapStmts(printer Printer,
 
 		dtype, ok := destType.(*types.Array)
 		if !ok {
-			return fmt.Errorf("type mismatch: %s and %s should be an array",
+			return configErrorf(CodeTypeMismatch, mapping.Position(fm, ""), "type mismatch: %s and %s should be an array",
 				sourceValue.DisplayName(), destValue.DisplayName())
 		}
 		// TODO: test slice and array and array size
@@ -1189,7 +1477,9 @@ func genFieldMapStmts(printer Printer,
 		n := mctx.NextVarCount()
 		p("\t\tvar tmp%d %s", n, GetSource(dtype.Elem(), mctx))
 		cfm := &FieldMapping{
-			Uses: fm.UsesForElements,
+			Uses:            fm.UsesForElements,
+			SourceFile:      fm.SourceFile,
+			SourcePositions: fm.SourcePositions,
 		}
 		if err := genFieldMapStmts(printer,
 			NewLocalMappingValue("elm", typ.Elem()),
@@ -1213,7 +1503,7 @@ func genFieldMapStmts(printer Printer,
 
 		dtype, ok := destType.(*types.Slice)
 		if !ok {
-			return fmt.Errorf("type mismatch: %s and %s should be a slice",
+			return configErrorf(CodeTypeMismatch, mapping.Position(fm, ""), "type mismatch: %s and %s should be a slice",
 				sourceValue.DisplayName(), destValue.DisplayName())
 		}
 		// TODO: test slice and array and array size
@@ -1235,7 +1525,9 @@ func genFieldMapStmts(printer Printer,
 		n := mctx.NextVarCount()
 		p("var tmp%d %s", n, GetSource(dtype.Elem(), mctx))
 		cfm := &FieldMapping{
-			Uses: fm.UsesForElements,
+			Uses:            fm.UsesForElements,
+			SourceFile:      fm.SourceFile,
+			SourcePositions: fm.SourcePositions,
 		}
 		if err := genFieldMapStmts(printer, NewLocalMappingValue("elm", typ.Elem()),
 			NewLocalMappingValue(fmt.Sprintf("tmp%d", n), dtype.Elem()), mapping, cfm, mctx); err != nil {
@@ -1255,7 +1547,8 @@ func genFieldMapStmts(printer Printer,
 		// TODO: support a conversion map and struct?
 		dtype, ok := destType.(*types.Map)
 		if !ok {
-			return fmt.Errorf("type mismatch: %s and %s should be a map", sourceType, destType)
+			return configErrorf(CodeTypeMismatch, mapping.Position(fm, ""), "type mismatch: %s and %s should be a map",
+				sourceType, destType)
 		}
 
 		p("if %s == nil {", sourceValue.GetGetterSource())
@@ -1274,7 +1567,9 @@ func genFieldMapStmts(printer Printer,
 		n := mctx.NextVarCount()
 		p("var tmp%d %s", n, GetSource(dtype.Elem(), mctx))
 		cfm := &FieldMapping{
-			Uses: fm.UsesForElements,
+			Uses:            fm.UsesForElements,
+			SourceFile:      fm.SourceFile,
+			SourcePositions: fm.SourcePositions,
 		}
 		if err := genFieldMapStmts(printer, NewLocalMappingValue("elm", typ.Elem()),
 			NewLocalMappingValue(fmt.Sprintf("tmp%d", n), dtype.Elem()),
@@ -1290,13 +1585,37 @@ func genFieldMapStmts(printer Printer,
 			destValue, "", mctx)
 		p("}")
 	case *types.Chan:
-		LogFunc(LogLevelInfo, "chan type %s ignored", sourceValue.DisplayName())
+		Logger.Info("Map field", "mapping", mctx.CurrentMapping(), "field", sourceValue.DisplayName(), "decision", "ignore-chan")
 	default:
 		genAssignStmt(printer, sourceValue, destValue, fm.UsesFuncID(typ, destType), mctx)
 	}
 	return nil
 }
 
+func genStrictCheckStmt(printer Printer, cf *ConverterFuncField, mf *MapperFuncField,
+	sourceType types.Type, destType types.Type, fid FuncID, mctx *MappingContext) {
+	p := printer.P
+	var conds []string
+	if cf != nil {
+		conds = append(conds, fmt.Sprintf("m.%s == nil", cf.FieldName))
+	}
+	if mf != nil {
+		conds = append(conds, fmt.Sprintf("m.%s == nil", mf.FieldName))
+	}
+	what := "a global function"
+	if id := fid.ObjectID(); len(id) != 0 {
+		what = id
+	}
+	msg := fmt.Sprintf("%s requires %s for %s -> %s, but it is not registered",
+		mctx.CurrentMapping(), what, GetQualifiedTypeName(sourceType), GetQualifiedTypeName(destType))
+	if loc := relativeLocation(mctx.Location()); len(loc) != 0 {
+		msg = loc + ": " + msg
+	}
+	p("if %s {", strings.Join(conds, " && "))
+	p("  return %s.New(%q)", mctx.GetImportAlias("errors"), msg)
+	p("}")
+}
+
 func genAssignStmt(printer Printer,
 	sourceValue MappingValue, destValue MappingValue, fid FuncID, mctx *MappingContext) {
 	p := printer.P
@@ -1329,6 +1648,29 @@ func genAssignStmt(printer Printer,
 	// Try to execute custom converter & mapper
 	cf := mctx.GetConverterFuncFieldName(sourceType, destType, fid)
 	mf := mctx.GetMapperFuncFieldName(sourceType, destType, fid)
+	decision := "assign"
+	if sourceTypeName != destTypeName {
+		decision = "cast"
+	}
+	if cf != nil || mf != nil {
+		optional := fid == "" && (sourceTypeName == destTypeName || CanCast(sourceType, destType))
+		if mctx.StaticDependency(fid.ObjectID()) == nil {
+			mctx.AddRequirement(fid.ObjectID(), sourceType, destType, optional)
+		}
+		if !optional && mctx.Strict() {
+			genStrictCheckStmt(printer, cf, mf, sourceType, destType, fid, mctx)
+		}
+		if optional {
+			decision = "function-or-" + decision
+		} else {
+			decision = "function"
+		}
+	}
+	if Logger.Enabled(context.Background(), slog.LevelDebug) {
+		Logger.Debug("Map field", "mapping", mctx.CurrentMapping(),
+			"source", sourceValue.DisplayName(), "dest", destValue.DisplayName(),
+			"decision", decision, "function", fid.ObjectID())
+	}
 	if cf != nil || mf != nil {
 		p("done%d := false", done)
 	}
@@ -1,44 +1,109 @@
 package internal
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"strings"
+	"sync"
 )
 
-// LogLevel is a level of the logging.
-type LogLevel int
+// LogFormat is a format of logs.
+type LogFormat string
 
 const (
-	// LogLevelDebug is a debug level log.
-	LogLevelDebug LogLevel = -4
+	// LogFormatText is a human readable format.
+	LogFormatText LogFormat = "text"
 
-	// LogLevelInfo is an info level log.
-	LogLevelInfo LogLevel = 0
+	// LogFormatJSON is a JSON format.
+	LogFormatJSON LogFormat = "json"
+)
 
-	// LogLevelWarn is a warning level log.
-	LogLevelWarn LogLevel = 4
+// ParseLogFormat parses a given string as a [LogFormat] .
+func ParseLogFormat(s string) (LogFormat, error) {
+	switch f := LogFormat(strings.ToLower(s)); f {
+	case LogFormatText, LogFormatJSON:
+		return f, nil
+	}
+	return "", fmt.Errorf("Unknown log format: %s(must be one of text or json)", s)
+}
 
-	// LogLevelError is an error level log.
-	LogLevelError LogLevel = 8
-)
+// LogLevel is a threshold for the logging.
+var LogLevel = new(slog.LevelVar)
+
+// Logger is a logger used in this package.
+var Logger = slog.New(NewPlainHandler(os.Stdout, os.Stderr, LogLevel))
+
+// SetLogFormat replaces [Logger] with a logger for given format.
+// If stderrOnly is true, all logs are written to stderr. This is useful when
+// stdout is used for machine-readable outputs.
+// JSON logs are always written to stderr.
+func SetLogFormat(format LogFormat, stderrOnly bool) {
+	switch {
+	case format == LogFormatJSON:
+		Logger = slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: LogLevel}))
+	case stderrOnly:
+		Logger = slog.New(NewPlainHandler(os.Stderr, os.Stderr, LogLevel))
+	default:
+		Logger = slog.New(NewPlainHandler(os.Stdout, os.Stderr, LogLevel))
+	}
+}
+
+// NewPlainHandler returns a [slog.Handler] that writes a message followed by
+// attributes like 'key=value'. Logs at warning level or above are written
+// to errOut.
+func NewPlainHandler(out, errOut io.Writer, level slog.Leveler) slog.Handler {
+	return &plainHandler{
+		out:    out,
+		errOut: errOut,
+		level:  level,
+		mu:     &sync.Mutex{},
+	}
+}
 
-// Log is a function for the logging.
-type Log func(level LogLevel, format string, args ...any)
+type plainHandler struct {
+	out    io.Writer
+	errOut io.Writer
+	level  slog.Leveler
+	attrs  []slog.Attr
+	mu     *sync.Mutex
+}
+
+func (h *plainHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
 
-// StdLog is a [Log] that writes to stdout and stderr.
-func StdLog(level LogLevel, format string, args ...any) {
-	out := os.Stdout
-	if level >= LogLevelWarn {
-		out = os.Stderr
+func (h *plainHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(r.Message)
+	write := func(a slog.Attr) bool {
+		fmt.Fprintf(&b, " %s=%v", a.Key, a.Value)
+		return true
 	}
-	if level >= LogEnabledFor {
-		fmt.Fprintf(out, format, args...)
-		fmt.Fprint(out, "\n")
+	for _, a := range h.attrs {
+		write(a)
 	}
+	r.Attrs(write)
+	b.WriteString("\n")
+
+	out := h.out
+	if r.Level >= slog.LevelWarn {
+		out = h.errOut
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(out, b.String())
+	return err
 }
 
-// LogFunc is a [Log] used in this package.
-var LogFunc Log = StdLog
+func (h *plainHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...)
+	return &h2
+}
 
-// LogEnabledFor is a threshold for the logging.
-var LogEnabledFor = LogLevelInfo
+func (h *plainHandler) WithGroup(_ string) slog.Handler {
+	return h
+}
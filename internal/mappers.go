@@ -1,5 +1,7 @@
 package internal
 
+import "fmt"
+
 const mapperGetterSrc = `interface {
 	Get(id string) (any, error)
 	GetAllMappers() (map[string]any, error)
@@ -7,6 +9,25 @@ const mapperGetterSrc = `interface {
 	GetFuncByTypeName(id string, sourceName string, destName string) (any, error)
 }`
 
+// observerID is an id of an observer for instrumented mappers.
+// This must be same as sesame.ObserverID .
+const observerID = "SesameObserver"
+
+// observerSrc returns a source code of the observer interface.
+// This must be compatible with sesame.Observer .
+func observerSrc(mctx *MappingContext) string {
+	return fmt.Sprintf("interface{ Start(%s.Context, string, string) func(error) }",
+		mctx.GetImportAlias("context"))
+}
+
+// sesamePackage is an import path of the sesame runtime package.
+const sesamePackage = "github.com/yuin/sesame"
+
+// requirementSrc returns a source code of the sesame.Requirement type.
+func requirementSrc(mctx *MappingContext) string {
+	return mctx.GetImportAlias(sesamePackage) + ".Requirement"
+}
+
 const mappersSrc = `
 import (
 	{{IMPORTS}}
@@ -14,11 +35,32 @@ import (
 )
 
 // NewMappers return a new [sesame.Mappers] .
-// Mappers are goroutine safe for get actions.
-// Mappers are not goroutine safe for add/merge actions.
-func NewMappers() sesame.Mappers {
-	mappers := 	sesame.NewMappers()
+// Mappers are goroutine safe.
+// Add/merge actions fail after [sesame.Mappers.Freeze] .
+// opts are passed to [sesame.NewMappers] .
+func NewMappers(opts ...sesame.MappersOption) sesame.Mappers {
+	mappers := 	sesame.NewMappers(opts...)
 	{{MAPPERS}}
 	return mappers
 }
+
+// IDs of generated mappers.
+const (
+	{{MAPPER_IDS}}
+)
+
+// GeneratedMappers is a [sesame.Mappers] with typed accessors for
+// generated mappers.
+type GeneratedMappers struct {
+	sesame.Mappers
+}
+
+// NewGeneratedMappers return a new [GeneratedMappers] .
+func NewGeneratedMappers(opts ...sesame.MappersOption) *GeneratedMappers {
+	return &GeneratedMappers{
+		Mappers: NewMappers(opts...),
+	}
+}
+
+{{MAPPER_ACCESSORS}}
 `
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"fmt"
+	"go/token"
 	"go/types"
 	"os"
 	"path/filepath"
@@ -62,6 +63,11 @@ func ParseFile(pkgPath string, mctx *MappingContext) (*types.Package, error) {
 			"probably that the package cannot be compiled.", pkgPath)
 	}
 	pkg := pkgs[0].Types
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		if p.Types != nil {
+			fcache.Store(p.Types, p.Fset)
+		}
+	})
 
 	mctx.AddImport(pkg.Path())
 	for _, imp := range pkg.Imports() {
@@ -71,6 +77,26 @@ func ParseFile(pkgPath string, mctx *MappingContext) (*types.Package, error) {
 	return pkg, nil
 }
 
+var fcache = sync.Map{}
+
+// GoPositionOf returns a position of the given object in Go source files.
+// GoPositionOf returns an invalid position if the object was not loaded by
+// [ParseFile] .
+func GoPositionOf(obj types.Object) Position {
+	if obj == nil || obj.Pkg() == nil {
+		return Position{}
+	}
+	v, ok := fcache.Load(obj.Pkg())
+	if !ok {
+		return Position{}
+	}
+	p := v.(*token.FileSet).Position(obj.Pos())
+	if !p.IsValid() {
+		return Position{}
+	}
+	return Position{File: p.Filename, Line: p.Line, Column: p.Column}
+}
+
 func findRootPath(path string) (string, error) {
 	start := filepath.Dir(path)
 	for cur := start; cur != filepath.Dir(cur); cur = filepath.Dir(cur) {
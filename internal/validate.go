@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// ValidateMapping checks that every field name referenced by the given
+// mapping exists in the operand structs a and b.
+func ValidateMapping(mapping *Mapping, a, b types.Object) []error {
+	var errs []error
+	validate := func(fm *FieldMapping, typ OperandType, obj types.Object) {
+		name := fm.Value(typ)
+		if len(name) == 0 || name == "*" {
+			return
+		}
+		if owner, err := validateMemberPath(obj, name, mapping.IgnoreCase); err != nil {
+			cerr := configErrorf(CodeUnknownField, fm.SourcePositions.Of(strings.ToLower(typ.String())), "%s", err)
+			cerr.GoPosition = GoPositionOf(owner)
+			errs = append(errs, cerr)
+		}
+	}
+	for _, fm := range mapping.Fields {
+		validate(fm, OperandA, a)
+		validate(fm, OperandB, b)
+	}
+	for _, fm := range mapping.Ignores {
+		validate(fm, OperandA, a)
+		validate(fm, OperandB, b)
+	}
+	return errs
+}
+
+// validateMemberPath returns an error if the given path does not exist in obj.
+// If the path does not exist, validateMemberPath also returns a type object
+// that should have the member.
+func validateMemberPath(obj types.Object, path string, ignoreCase bool) (types.Object, error) {
+	typ := obj.Type()
+	owner := obj
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		st, ok := GetStructType(typ)
+		if !ok {
+			return owner, fmt.Errorf("'%s' in '%s' is not a struct", strings.Join(parts[:i], "."), path)
+		}
+		named, nok := GetNamedType(typ)
+		if nok {
+			owner = named.Obj()
+		}
+		f, ok := GetField(st, part, ignoreCase)
+		if ok {
+			typ = f.Type()
+			continue
+		}
+		if i == len(parts)-1 && nok {
+			if _, ok := GetMethod(named, part, ignoreCase); ok {
+				return nil, nil
+			}
+			if _, ok := GetMethod(named, "Set"+part, ignoreCase); ok {
+				return nil, nil
+			}
+		}
+
+		typeName := typ.String()
+		if nok {
+			typeName = named.Obj().Pkg().Name() + "." + named.Obj().Name()
+		}
+		msg := fmt.Sprintf("Unknown field '%s' in %s", part, typeName)
+		if i != 0 {
+			msg += fmt.Sprintf(" (referenced by '%s')", path)
+		}
+		if suggestions := suggestMembers(st, named, part, i == len(parts)-1); len(suggestions) != 0 {
+			msg += fmt.Sprintf(", did you mean %s?", strings.Join(suggestions, " or "))
+		}
+		return owner, fmt.Errorf("%s", msg)
+	}
+	return nil, nil
+}
+
+const maxSuggestions = 3
+
+func suggestMembers(st *types.Struct, named *types.Named, name string, includeMethods bool) []string {
+	candidates := map[string]struct{}{}
+	for i := 0; i < st.NumFields(); i++ {
+		candidates[st.Field(i).Name()] = struct{}{}
+	}
+	if includeMethods && named != nil {
+		for i := 0; i < named.NumMethods(); i++ {
+			m := named.Method(i)
+			if !m.Exported() {
+				continue
+			}
+			if strings.HasPrefix(m.Name(), "Set") && GetParamsCount(m) == 1 {
+				candidates[strings.TrimPrefix(m.Name(), "Set")] = struct{}{}
+			} else if GetParamsCount(m) == 0 {
+				candidates[m.Name()] = struct{}{}
+			}
+		}
+	}
+
+	type scored struct {
+		name     string
+		distance int
+	}
+	threshold := len(name) / 3
+	if threshold < 2 {
+		threshold = 2
+	}
+	var lst []scored
+	for c := range candidates {
+		d := EditDistance(strings.ToLower(name), strings.ToLower(c))
+		if d <= threshold {
+			lst = append(lst, scored{c, d})
+		}
+	}
+	sort.Slice(lst, func(i, j int) bool {
+		if lst[i].distance != lst[j].distance {
+			return lst[i].distance < lst[j].distance
+		}
+		return lst[i].name < lst[j].name
+	})
+	var ret []string
+	for i := 0; i < len(lst) && i < maxSuggestions; i++ {
+		ret = append(ret, "'"+lst[i].name+"'")
+	}
+	return ret
+}
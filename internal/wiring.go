@@ -0,0 +1,183 @@
+package internal
+
+import (
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Wiring is a way to bind converters, mappers and helpers to generated
+// mappers.
+type Wiring string
+
+const (
+	// WiringDynamic binds objects at runtime by their ids.
+	WiringDynamic Wiring = "dynamic"
+
+	// WiringStatic binds objects at compile time by their types.
+	WiringStatic Wiring = "static"
+)
+
+// StaticDependency is a converter, mapper or helper that is bound to
+// a generated mapper at compile time.
+type StaticDependency struct {
+	// Ref is a reference written in configurations like './mapper.MyConverter'.
+	Ref string
+
+	// ObjectID is an object id that the dependency is registered with.
+	ObjectID string
+
+	// FieldName is a field name of the dependency in the Deps struct.
+	FieldName string
+
+	// Object is a type object of the dependency.
+	Object types.Object
+
+	// Optional is true if the mapper works without the dependency.
+	Optional bool
+
+	// Position is a position of the configuration that references the dependency.
+	Position Position
+}
+
+// ParseStaticRef splits a reference like './mapper.MyConverter' into
+// a package path and a type name.
+func ParseStaticRef(ref string) (string, string, bool) {
+	i := strings.LastIndex(ref, ".")
+	if i < 1 || i == len(ref)-1 {
+		return "", "", false
+	}
+	pkg, name := ref[:i], ref[i+1:]
+	if strings.ContainsAny(name, "/\\") || (!strings.ContainsAny(pkg, "/\\") && pkg != ".") {
+		return "", "", false
+	}
+	return pkg, name, true
+}
+
+// TypeSource returns a source code of the dependency type.
+func (d *StaticDependency) TypeSource(mctx *MappingContext) string {
+	src := GetSource(d.Object.Type(), mctx)
+	if types.IsInterface(d.Object.Type()) {
+		return src
+	}
+	return "*" + src
+}
+
+// FindMethod returns a name of the method that maps sourceType to destType.
+// If mapper is true, FindMethod finds a mapper method. Otherwise, it finds
+// a converter method.
+func (d *StaticDependency) FindMethod(sourceType, destType types.Type, mapper bool) (string, bool) {
+	typ := d.Object.Type()
+	if !types.IsInterface(typ) {
+		typ = types.NewPointer(typ)
+	}
+	sname := GetQualifiedTypeName(sourceType)
+	dname := GetQualifiedTypeName(destType)
+	mset := types.NewMethodSet(typ)
+	for i := 0; i < mset.Len(); i++ {
+		f, ok := mset.At(i).Obj().(*types.Func)
+		if !ok || !f.Exported() {
+			continue
+		}
+		sig := f.Type().(*types.Signature)
+		params, results := sig.Params(), sig.Results()
+		if mapper {
+			if params.Len() == 3 && results.Len() == 1 &&
+				GetQualifiedTypeName(params.At(1).Type()) == sname &&
+				GetQualifiedTypeName(params.At(2).Type()) == dname {
+				return f.Name(), true
+			}
+			continue
+		}
+		if params.Len() == 2 && (results.Len() == 2 || results.Len() == 3) &&
+			GetQualifiedTypeName(params.At(1).Type()) == sname &&
+			GetQualifiedTypeName(results.At(0).Type()) == dname {
+			return f.Name(), true
+		}
+	}
+	return "", false
+}
+
+// resolveStaticDependencies resolves objects referenced by the given
+// mapping that uses a static wiring.
+func resolveStaticDependencies(mapping *Mapping, mctx *MappingContext) ([]*StaticDependency, error) {
+	oldCwd, _ := os.Getwd()
+	rootPath, err := findRootPath(mapping.SourceFile)
+	if err != nil {
+		return nil, err
+	}
+	_ = os.Chdir(rootPath)
+	defer func() {
+		_ = os.Chdir(oldCwd)
+	}()
+
+	var deps []*StaticDependency
+	fieldNames := map[string]int{}
+	resolve := func(ref, sourceFile string, pos Position, optional bool) error {
+		for _, dep := range deps {
+			if dep.Ref == ref {
+				return nil
+			}
+		}
+		pkg, name, ok := ParseStaticRef(ref)
+		if !ok {
+			return configErrorf(CodeInvalidConfig, pos,
+				"'%s' must be a package path and a type name like './mapper.MyConverter' in static wiring", ref)
+		}
+		if !isModPackage(pkg) && !filepath.IsAbs(pkg) {
+			pkg = filepath.Join(filepath.Dir(sourceFile), pkg)
+		}
+		Logger.Info("Parse", "package", pkg, "type", name)
+		p, err := ParseFile(pkg, mctx)
+		if err != nil {
+			return configErrorf(CodeStructNotFound, pos, "%s", err)
+		}
+		obj := p.Scope().Lookup(name)
+		if _, ok := obj.(*types.TypeName); !ok {
+			return configErrorf(CodeStructNotFound, pos, "Type %s not found in %s", name, pkg)
+		}
+		fieldName := name
+		if n := fieldNames[name]; n != 0 {
+			fieldName = fmt.Sprintf("%s%d", name, n+1)
+		}
+		fieldNames[name]++
+		deps = append(deps, &StaticDependency{
+			Ref:       ref,
+			ObjectID:  name,
+			FieldName: fieldName,
+			Object:    obj,
+			Optional:  optional,
+			Position:  pos,
+		})
+		return nil
+	}
+
+	if len(mapping.Helper) != 0 {
+		fieldNames["Helper"]++
+		if err := resolve(mapping.Helper, mapping.SourceFile, mapping.SourcePositions.Of("helper"), true); err != nil {
+			return nil, err
+		}
+		deps[0].ObjectID = mapping.ID + "Helper"
+		deps[0].FieldName = "Helper"
+	}
+	for _, fm := range mapping.Fields {
+		sourceFile := fm.SourceFile
+		if len(sourceFile) == 0 {
+			sourceFile = mapping.SourceFile
+		}
+		if len(fm.Uses) != 0 {
+			if err := resolve(fm.Uses, sourceFile, fm.SourcePositions.Of("uses"), false); err != nil {
+				return nil, err
+			}
+		}
+		if len(fm.UsesForElements) != 0 {
+			if err := resolve(fm.UsesForElements, sourceFile,
+				fm.SourcePositions.Of("uses-for-elements"), false); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return deps, nil
+}
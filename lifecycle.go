@@ -0,0 +1,173 @@
+package sesame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// Lifecycle is a lifecycle of objects created by factories.
+type Lifecycle int
+
+const (
+	// Singleton objects are created once and shared. This is the default.
+	Singleton Lifecycle = iota
+
+	// Transient objects are created on every Get. Transient objects are
+	// not closed by [Mappers.Close] , callers own them.
+	Transient
+
+	// PerContext objects are created once per value of a context key
+	// given by [WithContextKey] . PerContext objects can be got only from
+	// [Mappers.ForContext] , and factories of [Singleton] objects
+	// can not get them.
+	// GetAllMappers without a context does not return PerContext mappers.
+	// Validate creates PerContext mappers with a placeholder context value
+	// and closes them after validation.
+	PerContext
+)
+
+// String implements fmt.Stringer.
+func (l Lifecycle) String() string {
+	switch l {
+	case Singleton:
+		return "singleton"
+	case Transient:
+		return "transient"
+	case PerContext:
+		return "per-context"
+	}
+	return fmt.Sprintf("Lifecycle(%d)", int(l))
+}
+
+// WithLifecycle sets a lifecycle of objects created by a factory.
+// WithLifecycle affects only AddFactory.
+//
+//	sesame.AddFactory(mappers, "TenantConverter", newTenantConverter,
+//		sesame.WithLifecycle(sesame.PerContext), sesame.WithContextKey(tenantKey{}))
+func WithLifecycle(lifecycle Lifecycle) AddOption {
+	return func(o *addOptions) {
+		o.Lifecycle = lifecycle
+	}
+}
+
+// WithContextKey sets a context key for [PerContext] objects.
+// Objects are created once per value of ctx.Value(key) .
+// Values must be comparable.
+func WithContextKey(key any) AddOption {
+	return func(o *addOptions) {
+		o.ContextKey = key
+	}
+}
+
+type objectFactory struct {
+	Create     func(MapperGetter) (any, error)
+	Lifecycle  Lifecycle
+	ContextKey any
+}
+
+type scopedKey struct {
+	id    string
+	value any
+}
+
+func (f *objectFactory) scopedKey(ctx context.Context, id string) (scopedKey, error) {
+	if ctx == nil {
+		return scopedKey{}, newError(ErrContextKey, id, "object %s is created per context, but no context is given", id)
+	}
+	v := ctx.Value(f.ContextKey)
+	if v == nil {
+		return scopedKey{}, newError(ErrContextKey, id, "context does not have a value for object %s", id)
+	}
+	if !reflect.TypeOf(v).Comparable() {
+		return scopedKey{}, newError(ErrContextKey, id, "context value %T for object %s is not comparable", v, id)
+	}
+	return scopedKey{id: id, value: v}, nil
+}
+
+type createdObject struct {
+	ID    string
+	Key   any
+	Cache *sync.Map
+	Obj   any
+}
+
+// forget removes the object from the cache unless it is already replaced.
+func (c *createdObject) forget() {
+	if reflect.TypeOf(c.Obj).Comparable() {
+		c.Cache.CompareAndDelete(c.Key, c.Obj)
+	} else {
+		c.Cache.Delete(c.Key)
+	}
+}
+
+func (d *mappers) track(id string, key any, cache *sync.Map, obj any) {
+	d.createdLock.Lock()
+	defer d.createdLock.Unlock()
+	d.created = append(d.created, createdObject{ID: id, Key: key, Cache: cache, Obj: obj})
+}
+
+// lifecycle returns a lifecycle of an object with given id.
+func (d *mappers) lifecycle(id string) Lifecycle {
+	if v, ok := d.dependencies.Load(id); ok && v != nil {
+		return Singleton
+	}
+	if f := d.lookupFactory(id); f != nil {
+		return f.Lifecycle
+	}
+	return Singleton
+}
+
+// forgetScoped removes [PerContext] objects with given id.
+func (d *mappers) forgetScoped(id string) {
+	d.scoped.Range(func(k, _ any) bool {
+		if k.(scopedKey).id == id {
+			d.scoped.Delete(k)
+		}
+		return true
+	})
+}
+
+func (d *mappers) ForContext(ctx context.Context) MapperGetter {
+	return &resolver{
+		mappers: d,
+		ctx:     ctx,
+	}
+}
+
+// Close calls Close methods of objects created by factories in reverse
+// creation order. Objects that do not implement io.Closer are just forgotten.
+// Close must not be called concurrently with Get actions.
+func (d *mappers) Close() error {
+	d.createdLock.Lock()
+	created := d.created
+	d.created = nil
+	d.createdLock.Unlock()
+	// Locks of failed factories remain.
+	d.creating.Range(func(k, _ any) bool {
+		d.creating.Delete(k)
+		return true
+	})
+
+	return d.closeObjects(created)
+}
+
+// closeObjects forgets given objects and calls their Close methods in
+// reverse order.
+func (d *mappers) closeObjects(created []createdObject) error {
+	var errs []error
+	for i := len(created) - 1; i >= 0; i-- {
+		c := created[i]
+		c.forget()
+		if closer, ok := c.Obj.(io.Closer); ok {
+			d.debug("Close an object", "id", c.ID)
+			if err := closer.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("failed to close %s: %w", c.ID, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,54 @@
+package sesame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type tenantKey struct{}
+
+type tenantConverter struct{}
+
+func TestPerContextLocks(t *testing.T) {
+	m := newMappers()
+	AddFactory(m, "TenantConverter", func(mg MapperGetter) (*tenantConverter, error) {
+		return &tenantConverter{}, nil
+	}, WithLifecycle(PerContext), WithContextKey(tenantKey{}))
+	AddFactory(m, "BrokenConverter", func(mg MapperGetter) (*tenantConverter, error) {
+		return nil, errors.New("broken")
+	}, WithLifecycle(PerContext), WithContextKey(tenantKey{}))
+
+	countLocks := func() int {
+		n := 0
+		m.creating.Range(func(_, _ any) bool {
+			n++
+			return true
+		})
+		return n
+	}
+	for i := 0; i < 100; i++ {
+		ctx := context.WithValue(context.Background(), tenantKey{}, fmt.Sprint(i))
+		if _, err := m.ForContext(ctx).Get("TenantConverter"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := m.ForContext(ctx).Get("BrokenConverter"); err == nil {
+			t.Fatal("BrokenConverter should fail")
+		}
+	}
+	// Only locks of failed factories remain.
+	if n := countLocks(); n != 100 {
+		t.Errorf("locks of created objects should be removed, but %d locks remain", n)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := countLocks(); n != 0 {
+		t.Errorf("locks should be removed after Close, but %d locks remain", n)
+	}
+	m.scoped.Range(func(k, _ any) bool {
+		t.Errorf("per-context objects should be removed after Close: %v", k)
+		return true
+	})
+}
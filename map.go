@@ -0,0 +1,146 @@
+package sesame
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Map maps src to a new D with a global mapper/converter function for S -> D .
+// Map accepts both mapper functions and converter functions, so callers do
+// not have to know which kind of function is registered.
+//
+//	user, err := sesame.Map[*model.UserModel, *domain.User](ctx, mappers, userModel)
+//	s, err := sesame.Map[*time.Time, string](ctx, mappers, &t)
+//
+// If D is a pointer type, Map allocates a new object for mapper functions.
+// S and D may be value types like model.UserModel, functions that take
+// pointers are used for them.
+func Map[S any, D any](ctx context.Context, mappers MapperGetter, src S) (D, error) {
+	var dest D
+	f, err := getMapFunc[S, D](ctx, mappers)
+	if err != nil {
+		return dest, err
+	}
+	if typ := reflect.TypeOf(&dest).Elem(); typ.Kind() == reflect.Ptr {
+		dest = reflect.New(typ.Elem()).Interface().(D)
+	}
+	if err := mapInto(ctx, f, src, &dest); err != nil {
+		var zero D
+		return zero, err
+	}
+	return dest, nil
+}
+
+// MapInto maps src into dest with a global mapper/converter function for S -> D .
+// See [Map] .
+//
+//	var user domain.User
+//	err := sesame.MapInto(ctx, mappers, userModel, &user)
+func MapInto[S any, D any](ctx context.Context, mappers MapperGetter, src S, dest *D) error {
+	f, err := getMapFunc[S, D](ctx, mappers)
+	if err != nil {
+		return err
+	}
+	if typ := reflect.TypeOf(dest).Elem(); typ.Kind() == reflect.Ptr && reflect.ValueOf(*dest).IsNil() {
+		*dest = reflect.New(typ.Elem()).Interface().(D)
+	}
+	return mapInto(ctx, f, src, dest)
+}
+
+// MapSlice maps each element of src with a global mapper/converter function
+// for S -> D . See [Map] .
+// MapSlice returns nil if src is nil.
+func MapSlice[S any, D any](ctx context.Context, mappers MapperGetter, src []S) ([]D, error) {
+	if src == nil {
+		return nil, nil
+	}
+	f, err := getMapFunc[S, D](ctx, mappers)
+	if err != nil {
+		return nil, err
+	}
+	typ := reflect.TypeOf((*D)(nil)).Elem()
+	ret := make([]D, len(src))
+	for i, s := range src {
+		if typ.Kind() == reflect.Ptr {
+			ret[i] = reflect.New(typ.Elem()).Interface().(D)
+		}
+		if err := mapInto(ctx, f, s, &ret[i]); err != nil {
+			return nil, fmt.Errorf("failed to map an element at %d: %w", i, err)
+		}
+	}
+	return ret, nil
+}
+
+// getMapFunc returns a function for S -> D . [PerContext] objects are
+// created for ctx if mappers is a [Mappers] .
+func getMapFunc[S any, D any](ctx context.Context, mappers MapperGetter) (any, error) {
+	if m, ok := mappers.(interface {
+		ForContext(context.Context) MapperGetter
+	}); ok {
+		mappers = m.ForContext(ctx)
+	}
+	return mappers.GetFunc("", getType[S](), getType[D]())
+}
+
+func mapInto[S any, D any](ctx context.Context, f any, src S, dest *D) error {
+	switch fn := f.(type) {
+	case func(context.Context, S, D) error:
+		return fn(ctx, src, *dest)
+	case func(context.Context, S, *D) error:
+		return fn(ctx, src, dest)
+	case func(context.Context, S) (D, error):
+		v, err := fn(ctx, src)
+		if err != nil {
+			return err
+		}
+		*dest = v
+	case func(context.Context, S) (D, bool, error):
+		v, isNil, err := fn(ctx, src)
+		if err != nil {
+			return err
+		}
+		if !isNil {
+			*dest = v
+		}
+	case func(context.Context, S) (*D, error):
+		v, err := fn(ctx, src)
+		if err != nil {
+			return err
+		}
+		if v != nil {
+			*dest = *v
+		}
+	// Functions take pointers, but S is a value type.
+	case func(context.Context, *S, *D) error:
+		return fn(ctx, &src, dest)
+	case func(context.Context, *S) (D, error):
+		v, err := fn(ctx, &src)
+		if err != nil {
+			return err
+		}
+		*dest = v
+	case func(context.Context, *S) (D, bool, error):
+		v, isNil, err := fn(ctx, &src)
+		if err != nil {
+			return err
+		}
+		if !isNil {
+			*dest = v
+		}
+	case func(context.Context, *S) (*D, error):
+		v, err := fn(ctx, &src)
+		if err != nil {
+			return err
+		}
+		if v != nil {
+			*dest = *v
+		}
+	default:
+		var s S
+		var d D
+		return newTypeMismatchError("", getType[S](), getType[D](), fmt.Sprintf(
+			"function for %T -> %T is %T, not a mapper/converter function", s, d, f))
+	}
+	return nil
+}
@@ -3,24 +3,80 @@ package sesame
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"reflect"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrNotFound is an error that an object or a function is not found.
+	ErrNotFound = errors.New("not found")
+
+	// ErrTypeMismatch is an error that an object or a function is not of
+	// an expected type.
+	ErrTypeMismatch = errors.New("type mismatch")
+
+	// ErrInvalidSignature is an error that functions have invalid signatures.
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// ErrFactoryFailed is an error that a factory returns an error.
+	ErrFactoryFailed = errors.New("factory failed")
+
+	// ErrCycle is an error that factories depend on each other cyclically.
+	ErrCycle = errors.New("cyclic dependency")
+
+	// ErrFrozen is an error that frozen mappers are modified.
+	ErrFrozen = errors.New("mappers are frozen")
+
+	// ErrConflict is an error that merged mappers conflict with existing ones.
+	ErrConflict = errors.New("mappers conflict")
+
+	// ErrContextKey is an error that a [PerContext] object does not have
+	// a context key, or a context does not have a comparable value for the key.
+	ErrContextKey = errors.New("invalid context key")
 )
 
 // Error is an error type for sesame.
+// Kinds of errors can be checked with errors.Is:
+//
+//	if errors.Is(err, sesame.ErrNotFound) {
+//	    var merr *sesame.Error
+//	    errors.As(err, &merr)
+//	    log.Printf("%s(%s -> %s) not found", merr.ID, merr.SourceType, merr.DestType)
+//	}
 type Error struct {
 	error
-	notFound    bool
+
+	// ID is an id of an object involved in the error.
+	// ID may be an empty string like global functions.
+	ID string
+
+	// SourceType is a source type name of a function involved in the error.
+	SourceType string
+
+	// DestType is a dest type name of a function involved in the error.
+	DestType string
+
+	kind        error
 	isMapper    bool
 	isConverter bool
 }
 
+// Is returns true if the error is a kind of target like [ErrNotFound] .
+func (e *Error) Is(target error) bool {
+	return e.kind != nil && e.kind == target
+}
+
 // NotFound returns true if the error is a not found error.
 func (e *Error) NotFound() bool {
-	return e.notFound
+	return e.kind == ErrNotFound
 }
 
 // IsMapper returns true if an object is a mapper, not a converter.
@@ -33,13 +89,26 @@ func (e *Error) IsConverter() bool {
 	return e.isConverter
 }
 
+// Frozen returns true if the error is caused by modifying frozen mappers.
+func (e *Error) Frozen() bool {
+	return e.kind == ErrFrozen
+}
+
+// Cycle returns true if the error is caused by a cyclic dependency
+// between factories.
+func (e *Error) Cycle() bool {
+	return e.kind == ErrCycle
+}
+
 func (e *Error) Unwrap() error {
 	return e.error
 }
 
-func merrorf(format string, a ...any) error {
+func newError(kind error, id string, format string, a ...any) *Error {
 	return &Error{
 		error: fmt.Errorf(format, a...),
+		ID:    id,
+		kind:  kind,
 	}
 }
 
@@ -49,10 +118,20 @@ type mfunc struct {
 	Func       reflect.Method
 	ObjectID   string
 	Global     bool
+
+	// Plain is true if an object itself is a function, not an object that
+	// has methods.
+	Plain bool
+
+	// AddedBy is an id that the function is added with.
+	AddedBy string
 }
 
 type addOptions struct {
-	NoGlobals bool
+	NoGlobals  bool
+	Strict     bool
+	Lifecycle  Lifecycle
+	ContextKey any
 }
 
 // AddOption is a type for mappers add operations.
@@ -65,6 +144,34 @@ func WithNoGlobals() AddOption {
 	}
 }
 
+// WithStrict validates signatures of methods that look like
+// converter/mapper functions. Methods that are named like 'XxxxToYyyy' or
+// take a context.Context as a first argument look like functions.
+// If some of them have invalid signatures, an add operation returns an error
+// that lists them and registers nothing.
+// Methods that have invalid signatures are never registered.
+func WithStrict() AddOption {
+	return func(o *addOptions) {
+		o.Strict = true
+	}
+}
+
+type mappersOptions struct {
+	Interceptors []Interceptor
+	Logger       *slog.Logger
+}
+
+// MappersOption is a type for options of [Mappers] .
+type MappersOption func(*mappersOptions)
+
+// WithLogger sets a logger. Mappers write how objects and functions are
+// resolved at debug level.
+func WithLogger(logger *slog.Logger) MappersOption {
+	return func(o *mappersOptions) {
+		o.Logger = logger
+	}
+}
+
 // MapperGetter is a getter interface for mappers.
 type MapperGetter interface {
 	// Get returns an object with given id.
@@ -110,7 +217,8 @@ type Mappers interface {
 	// Add adds given object to this mappers.
 	// Methods name like 'XxxxToYyyy' is automatically registered
 	// as a global mapper/converter functions.
-	Add(id string, mapper any, opts ...AddOption)
+	// Add returns an error if this mappers is frozen.
+	Add(id string, mapper any, opts ...AddOption) error
 
 	// AddFactory adds given object factory to this mappers.
 	//
@@ -121,196 +229,568 @@ type Mappers interface {
 	//
 	// Methods name like 'XxxxToYyyy' is automatically registered
 	// as a global mapper/converter functions.
+	// AddFactory returns an error if this mappers is frozen.
 	AddFactory(id string, typ reflect.Type, factory func(MapperGetter) (any, error),
-		opts ...AddOption)
+		opts ...AddOption) error
+
+	// AddFunc adds given function to this mappers.
+	// fn must be a converter function like func(context.Context, S) (D, error)
+	// or a mapper function like func(context.Context, S, D) error .
+	// Unlike Add, id and a function name do not have to follow the naming
+	// convention. fn is registered as a global function for S -> D .
+	// AddFunc returns an error if this mappers is frozen.
+	AddFunc(id string, fn any, opts ...AddOption) error
+
+	// MergeWith merges given mappers into this mapper with options and
+	// returns a report of conflicts. By default, conflicts are resolved
+	// by [Overwrite] like Merge.
+	// If a conflict policy is [ConflictError] , MergeWith merges nothing
+	// and returns an error when mappers conflict.
+	// If other is a child (see [Mappers.Child]), objects and functions of
+	// its parents are merged as the child sees them.
+	MergeWith(other MapperGetter, opts ...MergeOption) (*MergeReport, error)
+
+	// Remove removes an object, a factory and functions with given id.
+	// Objects created by factories that depend on the removed object and
+	// objects created in children (see [Mappers.Child]) are created again
+	// on the next Get.
+	// Remove returns an error if this mappers is frozen or
+	// the object is not found.
+	Remove(id string) error
+
+	// Replace replaces an object with given id by obj. Replace is same as
+	// Remove and Add, but is atomic. If obj is invalid, Replace keeps
+	// the old object.
+	// Replace returns an error if this mappers is frozen or
+	// the object is not found.
+	Replace(id string, obj any, opts ...AddOption) error
 
 	// Merge merges given mappers into this mapper.
 	// If same name object is already exists in this mappers, it will be overwritten by given mappers.
+	// Merge returns an error if this mappers is frozen.
 	Merge(MapperGetter) error
+
+	// Freeze makes this mappers read-only. After Freeze, Add, AddFactory,
+	// AddFunc, Remove, Replace, Merge and MergeWith return an error.
+	Freeze()
+
+	// Frozen returns true if this mappers is frozen.
+	Frozen() bool
+
+	// Child returns a new Mappers that overlays this mappers.
+	// Get actions of the child fall back to this mappers, but add actions of
+	// the child affect only the child.
+	Child() Mappers
+
+	// Describe returns registered objects and functions.
+	// Describe is intended for debugging.
+	Describe() *Description
+
+	// DependencyGraph returns ids of objects that each object depends on.
+	// Dependencies are recorded when factories get other objects.
+	DependencyGraph() map[string][]string
+
+	// Validate resolves all mappers including [PerContext] mappers and reports
+	// every mapper that can not be created and every converter, mapper
+	// and helper that is required by mappers but not registered.
+	// [PerContext] objects created by Validate are closed before it returns.
+	// Validate is intended to be called at startup:
+	//
+	//     if err := mappers.Validate(); err != nil {
+	//         log.Fatal(err)
+	//     }
+	Validate() error
+
+	// ForContext returns a [MapperGetter] that creates [PerContext] objects
+	// for given context.
+	ForContext(ctx context.Context) MapperGetter
+
+	// Close calls Close methods of objects created by factories
+	// in reverse creation order, and returns errors joined by errors.Join .
+	// Objects are created again on the next Get.
+	Close() error
+}
+
+// Requirement is an object that a generated mapper needs at runtime.
+// Generated mappers describe their requirements with a Requirements() method
+// that returns []Requirement .
+type Requirement struct {
+	// ObjectID is an object id of the required object.
+	// If a global function is required, ObjectID is an empty string.
+	ObjectID string
+
+	// SourceType is a source type name of the required function.
+	// SourceType is an empty string if the requirement is not a function.
+	SourceType string
+
+	// DestType is a dest type name of the required function.
+	// DestType is an empty string if the requirement is not a function.
+	DestType string
+
+	// Optional is true if the mapper works without the object.
+	Optional bool
+
+	// Location is a location of the configuration that needs the object.
+	Location string
 }
 
 type mappers struct {
 	dependencies sync.Map // [string, any]
-	factories    sync.Map // [string, func(MapperGetter) (any, error)]
+	factories    sync.Map // [string, *objectFactory]
 	findex       sync.Map // [string, []mfunc]
+	creating     sync.Map // [string | scopedKey, *sync.Mutex]
+	scoped       sync.Map // [scopedKey, any]
+	createdLock  sync.Mutex
+	created      []createdObject
+	lock         sync.Mutex
+	frozen       atomic.Bool
+	edges        sync.Map // [dependencyEdge, struct{}]
+	waitsLock    sync.Mutex
+	waits        map[string]string
+	parent       *mappers
+	generation   atomic.Uint64 // incremented when objects are removed
+	parentsSeen  atomic.Uint64 // parentsGeneration that created objects are based on
+	interceptors []Interceptor
+	logger       *slog.Logger
 }
 
-type concurrentMappers struct {
-	*mappers
-	lock sync.Mutex
+// NewMappers return a new [Mappers] .
+// Mappers are goroutine safe. Get actions do not take any locks once
+// objects are created. Factories are executed at most once per id (see [Lifecycle]),
+// and factories for different ids may run concurrently.
+// Add/merge actions are serialized, and fail after [Mappers.Freeze] .
+func NewMappers(opts ...MappersOption) Mappers {
+	return newMappers(opts...)
 }
 
-// NewMappers return a new [Mappers] .
-// Mappers are goroutine safe for get actions.
-// Mappers are not goroutine safe for add/merge actions.
-func NewMappers() Mappers {
-	mappers := &mappers{
-		dependencies: sync.Map{},
-		factories:    sync.Map{},
-		findex:       sync.Map{},
+func newMappers(opts ...MappersOption) *mappers {
+	options := mappersOptions{}
+	for _, o := range opts {
+		o(&options)
 	}
-	return &concurrentMappers{
-		mappers: mappers,
+	return &mappers{
+		interceptors: options.Interceptors,
+		logger:       options.Logger,
 	}
-
 }
 
 var funcNamePatter = regexp.MustCompile(`[A-Z][\w]+To[A-Z].*`)
 var mapperNameVersionSuffixPattern = regexp.MustCompile(`[vV]\d+`)
 
-func (d *concurrentMappers) Get(id string) (any, error) {
-	d.lock.Lock()
-	defer d.lock.Unlock()
-	return d.mappers.Get(id)
+func (d *mappers) Get(id string) (any, error) {
+	return d.get(nil, id, nil)
 }
 
-func (d *mappers) Get(id string) (any, error) {
-	if v, ok := d.dependencies.Load(id); !ok || v == nil {
-		factory, fok := d.factories.Load(id)
-		if fok && factory != nil {
-			obj, err := factory.(func(MapperGetter) (any, error))(d)
-			if err != nil {
-				merr := &Error{
-					error: err,
-				}
-				return nil, fmt.Errorf("failed to create a mapper: %w", merr)
-			}
-			d.dependencies.Store(id, obj)
-		} else {
-			merr := &Error{
-				error:    fmt.Errorf("object %s not found", id),
-				notFound: true,
-			}
-			return nil, merr
+// get returns an object with given id. chain is a list of ids that are
+// being created by factories in this call stack. ctx is nil if
+// [PerContext] objects can not be created.
+func (d *mappers) get(ctx context.Context, id string, chain []string) (any, error) {
+	parent := ""
+	if len(chain) == 0 {
+		d.syncParents()
+	} else {
+		parent = chain[len(chain)-1]
+		d.addEdge(parent, id)
+		if slices.Contains(chain, id) {
+			return nil, newCycleError(append(slices.Clone(chain), id))
+		}
+	}
+	if v, ok := d.dependencies.Load(id); ok && v != nil {
+		return v, nil
+	}
+	fv, fok := d.factories.Load(id)
+	if !fok || fv == nil {
+		var v any
+		v, fv = d.lookupParent(id)
+		if v != nil {
+			return v, nil
+		}
+	}
+	if fv == nil {
+		merr := newError(ErrNotFound, id, "object %s not found", id)
+		d.debug("Object not found", "id", id, "chain", chain)
+		return nil, merr
+	}
+
+	f := fv.(*objectFactory)
+	if f.Lifecycle == Transient {
+		return d.create(ctx, id, f, chain)
+	}
+	var key any = id
+	cache := &d.dependencies
+	if f.Lifecycle == PerContext {
+		k, err := f.scopedKey(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		key, cache = k, &d.scoped
+		if v, ok := cache.Load(key); ok {
+			return v, nil
+		}
+	}
+
+	// Factories are serialized per id, so that an object is created only once
+	// and slow factories do not block other ids.
+	lv, _ := d.creating.LoadOrStore(key, &sync.Mutex{})
+	lock := lv.(*sync.Mutex)
+	if err := d.beginWait(parent, id, chain); err != nil {
+		return nil, err
+	}
+	lock.Lock()
+	d.endWait(parent)
+	defer lock.Unlock()
+	if v, ok := cache.Load(key); ok && v != nil {
+		return v, nil
+	}
+	obj, err := d.create(ctx, id, f, chain)
+	if err != nil {
+		return nil, err
+	}
+	cache.Store(key, obj)
+	d.track(id, key, cache, obj)
+	// Goroutines that wait for the lock find the object in the cache, so
+	// the lock is no longer needed. This keeps creating from growing
+	// with PerContext context values.
+	d.creating.CompareAndDelete(key, lock)
+	return obj, nil
+}
+
+// create creates an object by given factory. Factories of [Singleton]
+// objects can not get [PerContext] objects, because singletons would hold
+// objects for the first context forever.
+func (d *mappers) create(ctx context.Context, id string, f *objectFactory, chain []string) (any, error) {
+	if f.Lifecycle == Singleton {
+		ctx = nil
+	}
+	d.debug("Create an object", "id", id, "chain", chain)
+	obj, err := f.Create(&resolver{
+		mappers: d,
+		ctx:     ctx,
+		chain:   append(slices.Clip(chain), id),
+	})
+	if err != nil {
+		d.debug("Failed to create an object", "id", id, "error", err)
+		var merr *Error
+		if errors.As(err, &merr) && merr.Cycle() {
+			return nil, fmt.Errorf("failed to create %s: %w", id, err)
+		}
+		merr = &Error{
+			error: err,
+			ID:    id,
+			kind:  ErrFactoryFailed,
 		}
+		return nil, fmt.Errorf("failed to create a mapper: %w", merr)
 	}
-	obj, _ := d.dependencies.Load(id)
 	return obj, nil
 }
 
 func (d *mappers) GetAllMappers() (map[string]any, error) {
+	return d.getAllMappers(nil, nil)
+}
+
+// getAllMappers returns all mappers. [PerContext] mappers are skipped
+// if ctx is nil.
+func (d *mappers) getAllMappers(ctx context.Context, chain []string) (map[string]any, error) {
 	mappers := map[string]any{}
 	var err error
-	var ids []string
-	d.dependencies.Range(func(key, _ any) bool {
-		ids = append(ids, key.(string))
-		return true
-	})
-	d.factories.Range(func(key, _ any) bool {
-		ids = append(ids, key.(string))
-		return true
-	})
-	for _, id := range ids {
-		if strings.HasSuffix(id, "Mapper") && !strings.Contains(id, ":") {
-			mappers[id], err = d.Get(id)
-			if err != nil {
-				return nil, err
-			}
+	for _, id := range d.mapperIDs() {
+		if ctx == nil && d.lifecycle(id) == PerContext {
+			continue
+		}
+		mappers[id], err = d.get(ctx, id, chain)
+		if err != nil {
+			return nil, err
 		}
 	}
 	return mappers, nil
 }
 
-func (d *concurrentMappers) GetAllMappers() (map[string]any, error) {
-	d.lock.Lock()
-	defer d.lock.Unlock()
-	return d.mappers.GetAllMappers()
-}
-
-func (d *concurrentMappers) GetFunc(id string, sourceType, destType reflect.Type) (any, error) {
-	d.lock.Lock()
-	defer d.lock.Unlock()
-	return d.mappers.GetFunc(id, sourceType, destType)
+// mapperIDs returns sorted ids of mappers in this mappers and parents.
+func (d *mappers) mapperIDs() []string {
+	ids := map[string]struct{}{}
+	for p := d; p != nil; p = p.parent {
+		p.dependencies.Range(func(key, _ any) bool {
+			ids[key.(string)] = struct{}{}
+			return true
+		})
+		p.factories.Range(func(key, _ any) bool {
+			ids[key.(string)] = struct{}{}
+			return true
+		})
+	}
+	var ret []string
+	for id := range ids {
+		if strings.HasSuffix(id, "Mapper") && !strings.Contains(id, ":") {
+			ret = append(ret, id)
+		}
+	}
+	sort.Strings(ret)
+	return ret
 }
 
 func (d *mappers) GetFunc(id string, sourceType, destType reflect.Type) (any, error) {
-	return d.GetFuncByTypeName(id, toTypeName(sourceType), toTypeName(destType))
+	return d.GetFuncByTypeName(id, TypeName(sourceType), TypeName(destType))
 }
 
-func (d *concurrentMappers) GetFuncByTypeName(id string, sourceName, destName string) (any, error) {
-	d.lock.Lock()
-	defer d.lock.Unlock()
-	return d.mappers.GetFuncByTypeName(id, sourceName, destName)
+func (d *mappers) GetFuncByTypeName(id string, sourceName, destName string) (any, error) {
+	return d.getFuncByTypeName(nil, id, sourceName, destName, nil)
 }
 
-func (d *mappers) GetFuncByTypeName(id string, sourceName, destName string) (any, error) {
-	v, ok := d.findex.Load(toTypeIndexFromString("func:", sourceName, destName))
-	if !ok {
-		merr := &Error{
-			error:    fmt.Errorf("functions for %s -> %s not found", sourceName, destName),
-			notFound: true,
-		}
+func (d *mappers) getFuncByTypeName(ctx context.Context, id string, sourceName, destName string,
+	chain []string) (any, error) {
+	lst := d.lookupFuncs(toTypeIndexFromString("func:", sourceName, destName))
+	if len(lst) == 0 {
+		d.debug("Function not found", "id", id, "source", sourceName, "dest", destName)
+		merr := newError(ErrNotFound, id, "functions for %s -> %s not found", sourceName, destName)
+		merr.SourceType, merr.DestType = sourceName, destName
 
 		return nil, merr
 	}
-	lst := v.([]mfunc)
 	// Merge method merges a given mapper to the end of the list, so we need to search from the end.
 	for i := len(lst) - 1; i >= 0; i-- {
 		if lst[i].Global && id == "" || lst[i].ObjectID == id {
-			obj, err := d.Get(lst[i].ObjectID)
+			obj, err := d.get(ctx, lst[i].ObjectID, chain)
 			if err != nil {
 				return nil, err
 			}
-			return reflect.ValueOf(obj).MethodByName(lst[i].Func.Name).Interface(), nil
+			d.debug("Resolve a function", "id", id, "source", sourceName, "dest", destName,
+				"object", lst[i].ObjectID, "method", lst[i].Func.Name)
+			if lst[i].Plain {
+				return d.intercept(obj, lst[i]), nil
+			}
+			method := reflect.ValueOf(obj).MethodByName(lst[i].Func.Name)
+			if !method.IsValid() {
+				merr := newError(ErrNotFound, lst[i].ObjectID, "%s does not have a method %s for %s -> %s",
+					lst[i].ObjectID, lst[i].Func.Name, sourceName, destName)
+				merr.SourceType, merr.DestType = sourceName, destName
+				return nil, merr
+			}
+			return d.intercept(method.Interface(), lst[i]), nil
 		}
 	}
-	merr := &Error{
-		error:    fmt.Errorf("functions for %s -> %s not found", sourceName, destName),
-		notFound: true,
-	}
+	d.debug("Function not found", "id", id, "source", sourceName, "dest", destName)
+	merr := newError(ErrNotFound, id, "functions for %s -> %s not found", sourceName, destName)
+	merr.SourceType, merr.DestType = sourceName, destName
 
 	return nil, merr
 }
 
-func (d *mappers) Add(id string, obj any, opts ...AddOption) {
+func (d *mappers) debug(msg string, args ...any) {
+	if d.logger != nil {
+		d.logger.Debug(msg, args...)
+	}
+}
+
+func (d *mappers) Validate() error {
+	// PerContext objects are validated with a placeholder context value.
+	ctx := validationContext{Context: context.Background()}
+	var errs []error
+	for _, id := range d.mapperIDs() {
+		obj, err := d.get(ctx, id, nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+		r, ok := obj.(interface{ Requirements() []Requirement })
+		if !ok {
+			continue
+		}
+		for _, req := range r.Requirements() {
+			if req.Optional {
+				continue
+			}
+			if len(req.SourceType) == 0 {
+				if _, err := d.get(ctx, req.ObjectID, nil); err != nil {
+					errs = append(errs, newRequirementError(id, req, err))
+				}
+				continue
+			}
+			if _, err := d.getFuncByTypeName(ctx, req.ObjectID, req.SourceType, req.DestType, nil); err != nil {
+				errs = append(errs, newRequirementError(id, req, err))
+			}
+		}
+	}
+	errs = append(errs, d.closeValidationObjects())
+	return errors.Join(errs...)
+}
+
+// closeValidationObjects closes [PerContext] objects created with
+// a placeholder context value, so that no one holds them.
+func (d *mappers) closeValidationObjects() error {
+	var objs []createdObject
+	d.createdLock.Lock()
+	d.created = slices.DeleteFunc(d.created, func(c createdObject) bool {
+		if k, ok := c.Key.(scopedKey); ok && k.value == (validationValue{}) {
+			objs = append(objs, c)
+			return true
+		}
+		return false
+	})
+	d.createdLock.Unlock()
+	return d.closeObjects(objs)
+}
+
+// validationContext is a context that has a placeholder value for any key.
+type validationContext struct {
+	context.Context
+}
+
+type validationValue struct{}
+
+func (c validationContext) Value(key any) any {
+	return validationValue{}
+}
+
+func newRequirementError(id string, req Requirement, cause error) error {
+	var what string
+	switch {
+	case len(req.SourceType) == 0:
+		what = req.ObjectID
+	case len(req.ObjectID) == 0:
+		what = fmt.Sprintf("a global function for %s -> %s", req.SourceType, req.DestType)
+	default:
+		what = fmt.Sprintf("%s for %s -> %s", req.ObjectID, req.SourceType, req.DestType)
+	}
+	var kind error
+	var merr *Error
+	if errors.As(cause, &merr) {
+		kind = merr.kind
+	}
+	msg := fmt.Errorf("%s requires %s: %w", id, what, cause)
+	if len(req.Location) != 0 {
+		msg = fmt.Errorf("%s:\t%s requires %s: %w", req.Location, id, what, cause)
+	}
+	return &Error{
+		error:      msg,
+		ID:         req.ObjectID,
+		SourceType: req.SourceType,
+		DestType:   req.DestType,
+		kind:       kind,
+	}
+}
+
+func (d *mappers) Add(id string, obj any, opts ...AddOption) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.Frozen() {
+		return newFrozenError(id, "add %s", id)
+	}
+	return d.add(id, obj, opts...)
+}
+
+func (d *mappers) add(id string, obj any, opts ...AddOption) error {
 	options := addOptions{}
 	for _, o := range opts {
 		o(&options)
 	}
 
+	funcs, err := d.funcs(id, reflect.TypeOf(obj), options.Strict)
+	if err != nil {
+		return err
+	}
+	// Functions of an old object with the same id must not remain.
+	_ = d.remove(id)
 	d.dependencies.Store(id, obj)
-	d.addMethods(id, reflect.TypeOf(obj), !options.NoGlobals)
+	d.addMethods(funcs, !options.NoGlobals)
+	return nil
 }
 
 func (d *mappers) AddFactory(id string, typ reflect.Type,
-	factory func(MapperGetter) (any, error), opts ...AddOption) {
+	factory func(MapperGetter) (any, error), opts ...AddOption) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.Frozen() {
+		return newFrozenError(id, "add a factory %s", id)
+	}
 	options := addOptions{}
 	for _, o := range opts {
 		o(&options)
 	}
-	d.factories.Store(id, func(di MapperGetter) (any, error) {
-		return factory(di)
+	if options.Lifecycle == PerContext && options.ContextKey == nil {
+		return newError(ErrContextKey, id, "%s is created per context, but a context key is not given", id)
+	}
+	funcs, err := d.funcs(id, typ, options.Strict)
+	if err != nil {
+		return err
+	}
+	_ = d.remove(id)
+	d.factories.Store(id, &objectFactory{
+		Create:     factory,
+		Lifecycle:  options.Lifecycle,
+		ContextKey: options.ContextKey,
 	})
-	d.addMethods(id, typ, !options.NoGlobals)
+	d.addMethods(funcs, !options.NoGlobals)
+	return nil
 }
 
-func (d *mappers) Merge(other MapperGetter) error {
-	ms, ok := other.(interface {
-		UnsafeDependencies() *sync.Map
-		UnsafeFactories() *sync.Map
-		UnsafeFuncIndex() *sync.Map
-	})
-	if !ok {
-		return merrorf("can not merge %T, must be a Mappers generated by the sesame", other)
+func (d *mappers) AddFunc(id string, fn any, opts ...AddOption) error {
+	ft := reflect.TypeOf(fn)
+	if ft == nil || ft.Kind() != reflect.Func {
+		return newError(ErrInvalidSignature, id, "%s is %T, but must be a function", id, fn)
+	}
+	var sourceType, destType reflect.Type
+	switch {
+	case isConverterFunc(ft, 0):
+		sourceType, destType = ft.In(1), ft.Out(0)
+		if ft.NumOut() == 2 && !isPointerPreferableType(destType) {
+			// Generated mappers use func(context.Context, S) (D, bool, error)
+			// for primitive types and unnamed interfaces and arrays.
+			f := reflect.ValueOf(fn)
+			wt := reflect.FuncOf([]reflect.Type{contextType, sourceType},
+				[]reflect.Type{destType, boolType, errorType}, false)
+			fn = reflect.MakeFunc(wt, func(args []reflect.Value) []reflect.Value {
+				ret := f.Call(args)
+				return []reflect.Value{ret[0], reflect.ValueOf(false), ret[1]}
+			}).Interface()
+		}
+	case isMapperFunc(ft, 0):
+		sourceType, destType = ft.In(1), ft.In(2)
+	default:
+		return newError(ErrInvalidSignature, id, "%s is %s, but must be func(context.Context, S) (D, error), "+
+			"func(context.Context, S) (D, bool, error) or func(context.Context, S, D) error",
+			id, funcSignature(ft, 0))
 	}
 
-	ms.UnsafeDependencies().Range(func(k, v any) bool {
-		d.dependencies.Store(k, v)
-		return true
-	})
-	ms.UnsafeFactories().Range(func(k, v any) bool {
-		d.factories.Store(k, v)
-		return true
-	})
-	ms.UnsafeFuncIndex().Range(func(k, v any) bool {
-		lv := v.([]mfunc)
-		fv, _ := d.findex.LoadOrStore(k, []mfunc{})
-		d.findex.Store(k, append(fv.([]mfunc), lv...))
-		return true
-	})
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.Frozen() {
+		return newFrozenError(id, "add a function %s", id)
+	}
+	options := addOptions{}
+	for _, o := range opts {
+		o(&options)
+	}
+	_ = d.remove(id)
+	d.dependencies.Store(id, fn)
+	d.addMethods([]mfunc{{
+		SourceType: sourceType,
+		DestType:   destType,
+		ObjectID:   id,
+		Plain:      true,
+		AddedBy:    id,
+	}}, !options.NoGlobals)
 	return nil
 }
 
+func (d *mappers) Freeze() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.frozen.Store(true)
+}
+
+func (d *mappers) Frozen() bool {
+	return d.frozen.Load()
+}
+
+func newFrozenError(id string, format string, a ...any) error {
+	return newError(ErrFrozen, id, "can not "+format+": mappers are frozen", a...)
+}
+
+func (d *mappers) Merge(other MapperGetter) error {
+	_, err := d.MergeWith(other)
+	return err
+}
+
 func (d *mappers) UnsafeDependencies() *sync.Map {
 	return &d.dependencies
 }
@@ -323,81 +803,217 @@ func (d *mappers) UnsafeFuncIndex() *sync.Map {
 	return &d.findex
 }
 
-func (d *mappers) addMethods(name string, typ reflect.Type, global bool) {
-	loc := mapperNameVersionSuffixPattern.FindAllStringIndex(name, -1)
+func (d *mappers) addMethods(funcs []mfunc, global bool) {
+	for _, f := range funcs {
+		f.Global = global
+		index := toTypeIndex("func:", f.SourceType, f.DestType)
+		lv, _ := d.findex.LoadOrStore(index, []mfunc{})
+		d.findex.Store(index, appendMfuncs(lv.([]mfunc), f))
+	}
+}
+
+// appendMfuncs appends fs to a copy of lst, so that readers that already
+// loaded lst never observe a modification.
+func appendMfuncs(lst []mfunc, fs ...mfunc) []mfunc {
+	ret := make([]mfunc, 0, len(lst)+len(fs))
+	ret = append(ret, lst...)
+	return append(ret, fs...)
+}
+
+// trimVersionSuffix removes a version suffix like 'V2' from given id.
+func trimVersionSuffix(id string) string {
+	loc := mapperNameVersionSuffixPattern.FindAllStringIndex(id, -1)
 	if len(loc) > 0 {
 		lastMatch := loc[len(loc)-1]
-		if lastMatch[1] == len(name) {
-			name = name[0:lastMatch[0]]
+		if lastMatch[1] == len(id) {
+			return id[0:lastMatch[0]]
 		}
 	}
+	return id
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	boolType    = reflect.TypeOf(false)
+)
+
+// funcs returns converter/mapper functions defined in typ.
+// If strict is true, funcs returns an error that lists methods that
+// look like functions but have invalid signatures.
+func (d *mappers) funcs(id string, typ reflect.Type, strict bool) ([]mfunc, error) {
+	name := trimVersionSuffix(id)
 	if strings.HasSuffix(name, "Helper") {
-		return
+		return nil, nil
 	}
 	if strings.HasPrefix(name, "func:") {
-		return
+		return nil, nil
 	}
 
-	for _, f := range d.funcs(name, typ) {
-		f.Global = global
-		index := toTypeIndex("func:", f.SourceType, f.DestType)
-		lv, _ := d.findex.LoadOrStore(index, []mfunc{})
-		d.findex.Store(index, append(lv.([]mfunc), f))
-	}
-}
-
-func (d *mappers) funcs(id string, typ reflect.Type) []mfunc {
 	var funcs []mfunc
+	var errs []error
 	offset := 1
 	if typ.Kind() == reflect.Interface {
 		offset = 0
 	}
-	if strings.HasSuffix(id, "Converter") {
+	if strings.HasSuffix(name, "Converter") {
 		for i := 0; i < typ.NumMethod(); i++ {
 			method := typ.Method(i)
 			ft := method.Type
+			if strict {
+				if isConverterFunc(ft, offset) {
+					funcs = append(funcs, mfunc{
+						SourceType: ft.In(1 + offset),
+						DestType:   ft.Out(0),
+						Func:       method,
+						ObjectID:   name,
+						AddedBy:    id,
+					})
+				} else if looksLikeFunc(method, offset) {
+					errs = append(errs, fmt.Errorf("%s.%s is %s, but a converter function must be "+
+						"func(context.Context, S) (D, error) or func(context.Context, S) (D, bool, error)",
+						id, method.Name, funcSignature(ft, offset)))
+				}
+				continue
+			}
 			if funcNamePatter.MatchString(method.Name) {
 				if ft.NumIn() != (2+offset) || (ft.NumOut() != 2 && ft.NumOut() != 3) {
 					continue
 				}
+			} else if !isConverterFunc(ft, offset) {
+				// Methods like Close are not functions.
+				continue
 			}
 			funcs = append(funcs, mfunc{
 				SourceType: ft.In(1 + offset),
 				DestType:   ft.Out(0),
 				Func:       method,
-				ObjectID:   id,
+				ObjectID:   name,
+				AddedBy:    id,
 			})
 
 		}
-	} else if strings.HasSuffix(id, "Mapper") {
+	} else if strings.HasSuffix(name, "Mapper") {
 		for i := 0; i < typ.NumMethod(); i++ {
 			method := typ.Method(i)
 			ft := method.Type
+			if strict {
+				if isMapperFunc(ft, offset) {
+					funcs = append(funcs, mfunc{
+						SourceType: ft.In(1 + offset),
+						DestType:   ft.In(2 + offset),
+						Func:       method,
+						ObjectID:   name,
+						AddedBy:    id,
+					})
+				} else if looksLikeFunc(method, offset) {
+					errs = append(errs, fmt.Errorf("%s.%s is %s, but a mapper function must be "+
+						"func(context.Context, S, D) error", id, method.Name, funcSignature(ft, offset)))
+				}
+				continue
+			}
 			if funcNamePatter.MatchString(method.Name) {
 				if ft.NumIn() != (3+offset) || ft.NumOut() != 1 {
 					continue
 				}
+			} else if !isMapperFunc(ft, offset) {
+				continue
 			}
 			funcs = append(funcs, mfunc{
 				SourceType: ft.In(1 + offset),
 				DestType:   ft.In(2 + offset),
 				Func:       method,
-				ObjectID:   id,
+				ObjectID:   name,
+				AddedBy:    id,
 			})
 		}
 	}
-	return funcs
+	if len(errs) != 0 {
+		return nil, newError(ErrInvalidSignature, id, "%s has methods with invalid signatures:\n%w",
+			id, errors.Join(errs...))
+	}
+	return funcs, nil
+}
+
+func isConverterFunc(ft reflect.Type, offset int) bool {
+	if ft.IsVariadic() || ft.NumIn() != 2+offset || ft.In(offset) != contextType {
+		return false
+	}
+	switch ft.NumOut() {
+	case 2:
+		return ft.Out(1) == errorType
+	case 3:
+		return ft.Out(1) == boolType && ft.Out(2) == errorType
+	}
+	return false
+}
+
+func isMapperFunc(ft reflect.Type, offset int) bool {
+	return !ft.IsVariadic() && ft.NumIn() == 3+offset && ft.In(offset) == contextType &&
+		ft.NumOut() == 1 && ft.Out(0) == errorType
+}
+
+func looksLikeFunc(method reflect.Method, offset int) bool {
+	return funcNamePatter.MatchString(method.Name) ||
+		(method.Type.NumIn() > offset && method.Type.In(offset) == contextType)
+}
+
+// funcSignature returns a signature of ft without a receiver.
+func funcSignature(ft reflect.Type, offset int) string {
+	var in, out []string
+	for i := offset; i < ft.NumIn(); i++ {
+		in = append(in, ft.In(i).String())
+	}
+	for i := 0; i < ft.NumOut(); i++ {
+		out = append(out, ft.Out(i).String())
+	}
+	sig := "func(" + strings.Join(in, ", ") + ")"
+	switch len(out) {
+	case 0:
+		return sig
+	case 1:
+		return sig + " " + out[0]
+	}
+	return sig + " (" + strings.Join(out, ", ") + ")"
 }
 
 // AddFactory adds a factory function to the given mappers.
 func AddFactory[T any](mappers Mappers, id string, factory func(MapperGetter) (T, error),
-	opts ...AddOption) {
+	opts ...AddOption) error {
 	rt := getType[T]()
-	mappers.AddFactory(id, rt, func(mg MapperGetter) (any, error) {
+	return mappers.AddFactory(id, rt, func(mg MapperGetter) (any, error) {
 		return factory(mg)
 	}, opts...)
 }
 
+// AddConverterFunc adds a converter function to the given mappers.
+// See [Mappers.AddFunc] .
+//
+// S should be a pointer type like *time.Time, because generated mappers
+// pass a pointer to a source field.
+//
+//	sesame.AddConverterFunc(mappers, "TimeToUnix",
+//	    func(ctx context.Context, source *time.Time) (int64, error) {
+//	        return source.Unix(), nil
+//	    })
+func AddConverterFunc[S any, D any](mappers Mappers, id string, f func(context.Context, S) (D, error),
+	opts ...AddOption) error {
+	return mappers.AddFunc(id, f, opts...)
+}
+
+// AddMapperFunc adds a mapper function to the given mappers.
+// See [Mappers.AddFunc] .
+// S and D must be struct pointer types.
+func AddMapperFunc[S any, D any](mappers Mappers, id string, f func(context.Context, S, D) error,
+	opts ...AddOption) error {
+	return mappers.AddFunc(id, f, opts...)
+}
+
+// AddStrict adds given object to the given mappers with [WithStrict] .
+func AddStrict(mappers Mappers, id string, obj any, opts ...AddOption) error {
+	return mappers.Add(id, obj, append(opts, WithStrict())...)
+}
+
 // Get returns an object with given id.
 func Get[T any](mappers MapperGetter, id string) (T, error) {
 	var iv T
@@ -407,7 +1023,7 @@ func Get[T any](mappers MapperGetter, id string) (T, error) {
 	}
 	v, ok := obj.(T)
 	if !ok {
-		return iv, merrorf("object %s is not a %T", id, iv)
+		return iv, newError(ErrTypeMismatch, id, "object %s is not a %T", id, iv)
 	}
 	return v, nil
 }
@@ -428,10 +1044,14 @@ func GetMapperFunc[T any, U any](mappers MapperGetter,
 		if ok {
 			return nil, &Error{
 				error:       fmt.Errorf("function is a converter function, not a mapper function"),
+				ID:          id,
+				SourceType:  TypeName(t1),
+				DestType:    TypeName(t2),
+				kind:        ErrTypeMismatch,
 				isConverter: true,
 			}
 		}
-		return nil, merrorf("function is not a mapper/converter function")
+		return nil, newTypeMismatchError(id, t1, t2, "function is not a mapper/converter function")
 	}
 	return v, nil
 }
@@ -448,7 +1068,7 @@ func GetToPrimitiveConverterFunc[T any, U any](mappers MapperGetter,
 	}
 	v, ok := f.(func(context.Context, T) (U, bool, error))
 	if !ok {
-		return nil, fmt.Errorf("function is not a converter function")
+		return nil, newTypeMismatchError(id, t1, t2, "function is not a converter function")
 	}
 	return v, nil
 }
@@ -468,11 +1088,21 @@ func GetToObjectConverterFunc[T any, U any](mappers MapperGetter,
 		_, ok := f.(func(context.Context, T, U) error)
 		if ok {
 			return nil, &Error{
-				error:    fmt.Errorf("function is a mapper function, not a converter function"),
-				isMapper: true,
+				error:      fmt.Errorf("function is a mapper function, not a converter function"),
+				ID:         id,
+				SourceType: TypeName(t1),
+				DestType:   TypeName(t2),
+				kind:       ErrTypeMismatch,
+				isMapper:   true,
 			}
 		}
-		return nil, merrorf("function is not a converter/mapper function")
+		return nil, newTypeMismatchError(id, t1, t2, "function is not a converter/mapper function")
 	}
 	return v, nil
 }
+
+func newTypeMismatchError(id string, sourceType, destType reflect.Type, msg string) error {
+	merr := newError(ErrTypeMismatch, id, "%s", msg)
+	merr.SourceType, merr.DestType = TypeName(sourceType), TypeName(destType)
+	return merr
+}
@@ -0,0 +1,201 @@
+package sesame
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ConflictPolicy is a policy to resolve conflicts in [Mappers.MergeWith] .
+type ConflictPolicy int
+
+const (
+	// Overwrite overwrites existing objects by merged objects, and merged
+	// global functions take precedence over existing ones.
+	Overwrite ConflictPolicy = iota
+
+	// KeepExisting keeps existing objects, and existing global functions
+	// take precedence over merged ones.
+	KeepExisting
+
+	// ConflictError makes [Mappers.MergeWith] fail if mappers conflict.
+	ConflictError
+)
+
+type mergeOptions struct {
+	Policy ConflictPolicy
+}
+
+// MergeOption is a type for mappers merge operations.
+type MergeOption func(*mergeOptions)
+
+// OnConflict sets a policy to resolve conflicts.
+func OnConflict(policy ConflictPolicy) MergeOption {
+	return func(o *mergeOptions) {
+		o.Policy = policy
+	}
+}
+
+// FunctionConflict is a global function type pair that is defined in both
+// mappers.
+type FunctionConflict struct {
+	// SourceType is a source type name of the function.
+	SourceType string
+
+	// DestType is a destination type name of the function.
+	DestType string
+
+	// ExistingIDs is ids of objects that define the function in existing mappers.
+	ExistingIDs []string
+
+	// MergedIDs is ids of objects that define the function in merged mappers.
+	MergedIDs []string
+}
+
+// MergeReport is a report of [Mappers.MergeWith] .
+type MergeReport struct {
+	// ObjectIDs is ids of objects that are defined in both mappers.
+	ObjectIDs []string
+
+	// Functions is global functions that are defined in both mappers.
+	Functions []FunctionConflict
+}
+
+// HasConflicts returns true if mappers conflict.
+func (r *MergeReport) HasConflicts() bool {
+	return len(r.ObjectIDs) != 0 || len(r.Functions) != 0
+}
+
+// String returns a human readable report.
+func (r *MergeReport) String() string {
+	var lines []string
+	for _, id := range r.ObjectIDs {
+		lines = append(lines, fmt.Sprintf("object %s is defined in both mappers", id))
+	}
+	for _, f := range r.Functions {
+		lines = append(lines, fmt.Sprintf("global function %s -> %s is defined by %s and %s",
+			f.SourceType, f.DestType, strings.Join(f.ExistingIDs, ", "), strings.Join(f.MergedIDs, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (d *mappers) MergeWith(other MapperGetter, opts ...MergeOption) (*MergeReport, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.Frozen() {
+		return nil, newFrozenError("", "merge mappers")
+	}
+	options := mergeOptions{}
+	for _, o := range opts {
+		o(&options)
+	}
+	ms, ok := other.(interface {
+		UnsafeDependencies() *sync.Map
+		UnsafeFactories() *sync.Map
+		UnsafeFuncIndex() *sync.Map
+	})
+	if !ok {
+		return nil, newError(ErrTypeMismatch, "", "can not merge %T, must be a Mappers generated by the sesame", other)
+	}
+	if m, ok := other.(*mappers); ok && m.parent != nil {
+		ms = m.flatten()
+	}
+
+	report := &MergeReport{}
+	conflicts := map[string]bool{}
+	collect := func(k, _ any) bool {
+		id := k.(string)
+		if conflicts[id] {
+			return true
+		}
+		_, dok := d.dependencies.Load(id)
+		_, fok := d.factories.Load(id)
+		if dok || fok {
+			conflicts[id] = true
+			report.ObjectIDs = append(report.ObjectIDs, id)
+		}
+		return true
+	}
+	ms.UnsafeDependencies().Range(collect)
+	ms.UnsafeFactories().Range(collect)
+	sort.Strings(report.ObjectIDs)
+
+	ms.UnsafeFuncIndex().Range(func(k, v any) bool {
+		fv, ok := d.findex.Load(k)
+		if !ok {
+			return true
+		}
+		existing := globalFuncOwners(fv.([]mfunc))
+		merged := globalFuncOwners(v.([]mfunc))
+		if len(existing) != 0 && len(merged) != 0 {
+			f := v.([]mfunc)[0]
+			report.Functions = append(report.Functions, FunctionConflict{
+				SourceType:  TypeName(f.SourceType),
+				DestType:    TypeName(f.DestType),
+				ExistingIDs: existing,
+				MergedIDs:   merged,
+			})
+		}
+		return true
+	})
+	sort.Slice(report.Functions, func(i, j int) bool {
+		if report.Functions[i].SourceType != report.Functions[j].SourceType {
+			return report.Functions[i].SourceType < report.Functions[j].SourceType
+		}
+		return report.Functions[i].DestType < report.Functions[j].DestType
+	})
+
+	switch options.Policy {
+	case ConflictError:
+		if report.HasConflicts() {
+			return report, newError(ErrConflict, "", "mappers conflict:\n%s", report)
+		}
+	case Overwrite:
+		for _, id := range report.ObjectIDs {
+			_ = d.remove(id)
+		}
+	}
+
+	skip := func(id string) bool {
+		return options.Policy == KeepExisting && conflicts[id]
+	}
+	ms.UnsafeDependencies().Range(func(k, v any) bool {
+		if !skip(k.(string)) {
+			d.dependencies.Store(k, v)
+		}
+		return true
+	})
+	ms.UnsafeFactories().Range(func(k, v any) bool {
+		if !skip(k.(string)) {
+			d.factories.Store(k, v)
+		}
+		return true
+	})
+	ms.UnsafeFuncIndex().Range(func(k, v any) bool {
+		lv := slices.DeleteFunc(slices.Clone(v.([]mfunc)), func(f mfunc) bool {
+			return skip(f.AddedBy)
+		})
+		fv, _ := d.findex.LoadOrStore(k, []mfunc{})
+		if options.Policy == KeepExisting {
+			// Functions are searched from the end of the list.
+			d.findex.Store(k, appendMfuncs(lv, fv.([]mfunc)...))
+		} else {
+			d.findex.Store(k, appendMfuncs(fv.([]mfunc), lv...))
+		}
+		return true
+	})
+	return report, nil
+}
+
+func globalFuncOwners(lst []mfunc) []string {
+	var ids []string
+	for _, f := range lst {
+		if f.Global && !slices.Contains(ids, f.AddedBy) {
+			ids = append(ids, f.AddedBy)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
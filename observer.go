@@ -0,0 +1,39 @@
+package sesame
+
+import (
+	"context"
+)
+
+// ObserverID is an id of the [Observer] for instrumented mappers.
+const ObserverID = "SesameObserver"
+
+// Observer observes calls of generated mappers that are generated with
+// the 'instrument' option.
+// Start is called when a mapping starts, and a returned function is called
+// with an error of the mapping when the mapping ends.
+//
+//	sesame.AddObserver(mappers, sesame.ObserverFunc(
+//	    func(ctx context.Context, mapper, method string) func(error) {
+//	        start := time.Now()
+//	        return func(err error) {
+//	            histogram.Observe(mapper, method, err != nil, time.Since(start))
+//	        }
+//	    }))
+type Observer interface {
+	Start(ctx context.Context, mapper, method string) func(error)
+}
+
+// ObserverFunc is an adapter to use a function as an [Observer] .
+type ObserverFunc func(ctx context.Context, mapper, method string) func(error)
+
+// Start implements [Observer.Start] .
+func (f ObserverFunc) Start(ctx context.Context, mapper, method string) func(error) {
+	return f(ctx, mapper, method)
+}
+
+// AddObserver adds given observer to the given mappers.
+// Instrumented mappers resolve the observer when they are created,
+// so the observer should be added before mappers are used.
+func AddObserver(mappers Mappers, observer Observer) error {
+	return mappers.Add(ObserverID, observer)
+}
@@ -0,0 +1,820 @@
+package sesame
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// NilCollection defines how nil maps and nil slices are mapped by
+// a [ReflectMapper] .
+type NilCollection int
+
+const (
+	// NilCollectionAsNil maps nil collections as a nil.
+	NilCollectionAsNil NilCollection = iota
+
+	// NilCollectionAsEmpty maps nil collections as empty collections.
+	NilCollectionAsEmpty
+)
+
+// ReflectField is a definition of how a field is mapped by a [ReflectMapper] .
+// This is the same as an entry of 'fields' and 'ignores' in configuration files.
+type ReflectField struct {
+	// A is a name of the field defined in A. This can be a dot
+	// separated path like 'User.ID' .
+	A string
+
+	// B is a name of the field defined in B. This can be a dot
+	// separated path like 'User.ID' .
+	B string
+
+	// Uses is an id of a mapper/converter used to map this field.
+	Uses string
+
+	// UsesForElements is an id of a mapper/converter used to map elements of this field.
+	UsesForElements string
+}
+
+func (f *ReflectField) value(fromA bool) string {
+	if fromA {
+		return f.A
+	}
+	return f.B
+}
+
+// ReflectMapping is a definition of the mapping for a [ReflectMapper] .
+// This is the same as a mapping in configuration files, so a ReflectMapper can
+// be replaced with a generated mapper without changing behaviors.
+type ReflectMapping struct {
+	// ExplicitOnly indicates that implicit mappings should not be
+	// performed.
+	ExplicitOnly bool
+
+	// IgnoreCase means this mapping ignores field name casing.
+	IgnoreCase bool
+
+	// AllowUnmapped is set true, a ReflectMapper does not fail if unmapped
+	// field exists.
+	AllowUnmapped bool
+
+	// Fields is definitions of how fields will be mapped.
+	Fields []ReflectField
+
+	// Ignores is definitions of the fields should be ignored.
+	// Each entry must define either A or B.
+	Ignores []ReflectField
+
+	// NilMap defines how are nil maps are mapped.
+	NilMap NilCollection
+
+	// NilSlice defines how are nil slices are mapped.
+	NilSlice NilCollection
+
+	// Helper is an id of the helper. Generated mappers use an object
+	// with the id '<mapping name>Helper' as a helper.
+	Helper string
+}
+
+func (m *ReflectMapping) ignores(fromA bool, name string) bool {
+	for _, f := range m.Ignores {
+		if f.value(fromA) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ReflectMapper is a mapper that maps A and B at runtime by reflection.
+// ReflectMapper maps objects with the same rules as generated mappers:
+// name matching, ignore-case, nested dot paths, global converters and
+// nil-map/nil-slice policies.
+//
+// ReflectMapper is useful for prototyping new mappings without running
+// `sesame generate` . ReflectMapper is much slower than generated mappers,
+// so it is recommended to replace it with a generated mapper later.
+//
+//	sesame.AddFactory(mappers, "UserMapper",
+//		func(mg sesame.MapperGetter) (*sesame.ReflectMapper[model.UserModel, domain.User], error) {
+//			return sesame.NewReflectMapper[model.UserModel, domain.User](mg, sesame.ReflectMapping{
+//				IgnoreCase: true,
+//			})
+//		})
+type ReflectMapper[A any, B any] struct {
+	aToB       []*reflectStep
+	bToA       []*reflectStep
+	helperAToB reflect.Value
+	helperBToA reflect.Value
+}
+
+// NewReflectMapper creates a new [ReflectMapper] for A and B .
+// A and B must be struct types.
+// Mapper/converter functions used by the mapper are resolved by the
+// mapperGetter when the mapper is created, like generated mappers.
+// NewReflectMapper returns an error if fields can not be mapped.
+func NewReflectMapper[A any, B any](mapperGetter MapperGetter, mapping ReflectMapping) (*ReflectMapper[A, B], error) {
+	ta := reflect.TypeOf((*A)(nil)).Elem()
+	tb := reflect.TypeOf((*B)(nil)).Elem()
+	for _, t := range []reflect.Type{ta, tb} {
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("%s is not a struct", t)
+		}
+	}
+	p := &reflectPlanner{
+		mapperGetter: mapperGetter,
+		mapping:      &mapping,
+	}
+	m := &ReflectMapper[A, B]{}
+	var err error
+	if m.aToB, err = p.planObject(ta, tb, nil, mapping.Fields, mapping.ExplicitOnly, true); err != nil {
+		return nil, err
+	}
+	if m.bToA, err = p.planObject(tb, ta, nil, mapping.Fields, mapping.ExplicitOnly, false); err != nil {
+		return nil, err
+	}
+	if len(mapping.Helper) != 0 {
+		if helper, err := mapperGetter.Get(mapping.Helper); err == nil {
+			m.helperAToB = findMethodByType(helper, reflect.TypeOf(m.AToB))
+			m.helperBToA = findMethodByType(helper, reflect.TypeOf(m.BToA))
+		}
+	}
+	return m, nil
+}
+
+// AToB maps source into dest.
+func (m *ReflectMapper[A, B]) AToB(ctx context.Context, source *A, dest *B) error {
+	return runReflectSteps(ctx, m.aToB, m.helperAToB, reflect.ValueOf(source), reflect.ValueOf(dest))
+}
+
+// BToA maps source into dest.
+func (m *ReflectMapper[A, B]) BToA(ctx context.Context, source *B, dest *A) error {
+	return runReflectSteps(ctx, m.bToA, m.helperBToA, reflect.ValueOf(source), reflect.ValueOf(dest))
+}
+
+func runReflectSteps(ctx context.Context, steps []*reflectStep, helper reflect.Value,
+	source, dest reflect.Value) error {
+	sv := source.Elem()
+	dv := dest.Elem()
+	for _, step := range steps {
+		s, ok := step.source.get(sv)
+		if !ok {
+			continue
+		}
+		if err := step.value.run(ctx, s, step.dest.target(dv)); err != nil {
+			return err
+		}
+	}
+	if helper.IsValid() {
+		if err := callFunc(helper, reflect.ValueOf(ctx), source, dest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func findMethodByType(obj any, typ reflect.Type) reflect.Value {
+	v := reflect.ValueOf(obj)
+	for i := 0; i < v.NumMethod(); i++ {
+		if m := v.Method(i); m.Type() == typ {
+			return m
+		}
+	}
+	return reflect.Value{}
+}
+
+func callFunc(f reflect.Value, args ...reflect.Value) error {
+	out := f.Call(args)
+	if err := out[len(out)-1]; !err.IsNil() {
+		return err.Interface().(error)
+	}
+	return nil
+}
+
+// reflectStep maps a field.
+type reflectStep struct {
+	source *reflectProperty
+	dest   *reflectProperty
+	value  *reflectValuePlan
+}
+
+// reflectProperty is a field or a pair of a getter and a setter.
+type reflectProperty struct {
+	typ reflect.Type
+
+	// path is indices of fields. If this property is accessed by methods,
+	// path points a struct that has methods.
+	path   []int
+	getter string
+	setter string
+}
+
+func (p *reflectProperty) canGet() bool {
+	return p.getter != "" || p.setter == ""
+}
+
+func (p *reflectProperty) canSet() bool {
+	return p.setter != "" || p.getter == ""
+}
+
+func (p *reflectProperty) get(v reflect.Value) (reflect.Value, bool) {
+	for _, i := range p.path {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	if len(p.getter) == 0 {
+		return v, true
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	return v.Addr().MethodByName(p.getter).Call(nil)[0], true
+}
+
+func (p *reflectProperty) target(v reflect.Value) *reflectTarget {
+	for _, i := range p.path {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	if len(p.setter) == 0 {
+		return &reflectTarget{value: v}
+	}
+	return &reflectTarget{setter: v.Addr().MethodByName(p.setter)}
+}
+
+// reflectTarget is a destination of the mapping.
+type reflectTarget struct {
+	// value is an addressable value. If value is invalid, setter is used
+	// to set a value.
+	value  reflect.Value
+	setter reflect.Value
+}
+
+func (t *reflectTarget) set(v reflect.Value) {
+	if t.value.IsValid() {
+		t.value.Set(v)
+		return
+	}
+	t.setter.Call([]reflect.Value{v})
+}
+
+// reflectValuePlan is a plan to map a value.
+type reflectValuePlan struct {
+	source reflect.Type
+	dest   reflect.Type
+
+	// elem is a plan for elements of arrays, slices and maps.
+	elem          *reflectValuePlan
+	nilCollection NilCollection
+
+	converter reflect.Value
+	mapper    reflect.Value
+	assign    bool
+	cast      bool
+}
+
+func (p *reflectValuePlan) run(ctx context.Context, source reflect.Value, dest *reflectTarget) error {
+	if p.elem != nil {
+		return p.runElements(ctx, source, dest)
+	}
+	done := false
+	if p.converter.IsValid() {
+		done = true
+		arg := source
+		if !isNillableType(p.source) {
+			arg = addrOf(source)
+		}
+		out := p.converter.Call([]reflect.Value{reflect.ValueOf(ctx), arg})
+		if err := out[len(out)-1]; !err.IsNil() {
+			return err.Interface().(error)
+		}
+		converted := out[0]
+		switch {
+		case len(out) == 2 && isNillableType(p.dest):
+			dest.set(converted)
+		case len(out) == 2:
+			if !converted.IsNil() {
+				dest.set(converted.Elem())
+			}
+		case isNillableType(p.dest):
+			if out[1].Bool() {
+				dest.set(reflect.Zero(p.dest))
+			} else {
+				dest.set(addrOf(converted))
+			}
+		default:
+			if !out[1].Bool() {
+				dest.set(converted)
+			}
+		}
+	}
+	if p.mapper.IsValid() && !done && (source.Kind() != reflect.Ptr || !source.IsNil()) {
+		done = true
+		arg := source
+		if source.Kind() != reflect.Ptr {
+			arg = addrOf(source)
+		}
+		if dest.value.IsValid() {
+			d := dest.value
+			if d.Kind() == reflect.Ptr {
+				if d.IsNil() {
+					d.Set(reflect.New(p.dest.Elem()))
+				}
+			} else {
+				d = d.Addr()
+			}
+			if err := callFunc(p.mapper, reflect.ValueOf(ctx), arg, d); err != nil {
+				return err
+			}
+		} else {
+			d := reflect.New(toValueType(p.dest))
+			if err := callFunc(p.mapper, reflect.ValueOf(ctx), arg, d); err != nil {
+				return err
+			}
+			if p.dest.Kind() != reflect.Ptr {
+				d = d.Elem()
+			}
+			dest.set(d)
+		}
+	}
+	if done {
+		return nil
+	}
+	switch {
+	case p.assign:
+		sourceIsNillable := isNillableType(p.source)
+		destIsNillable := isNillableType(p.dest)
+		switch {
+		case sourceIsNillable && destIsNillable:
+			dest.set(source)
+		case sourceIsNillable && !destIsNillable:
+			if !source.IsNil() {
+				dest.set(source.Elem())
+			}
+		case !sourceIsNillable && destIsNillable:
+			dest.set(addrOf(source))
+		default:
+			dest.set(source)
+		}
+	case p.cast:
+		dest.set(source.Convert(p.dest))
+	}
+	return nil
+}
+
+func (p *reflectValuePlan) runElements(ctx context.Context, source reflect.Value, dest *reflectTarget) error {
+	if source.Kind() != reflect.Array && source.IsNil() {
+		if p.nilCollection == NilCollectionAsEmpty {
+			if p.dest.Kind() == reflect.Map {
+				dest.set(reflect.MakeMap(p.dest))
+			} else {
+				dest.set(reflect.MakeSlice(p.dest, 0, 0))
+			}
+		} else {
+			dest.set(reflect.Zero(p.dest))
+		}
+		return nil
+	}
+	mapElem := func(elm reflect.Value) (reflect.Value, error) {
+		tmp := reflect.New(p.dest.Elem()).Elem()
+		err := p.elem.run(ctx, copyOf(elm), &reflectTarget{value: tmp})
+		return tmp, err
+	}
+	var ret reflect.Value
+	switch p.source.Kind() {
+	case reflect.Array:
+		ret = reflect.New(p.dest).Elem()
+		for i := 0; i < source.Len() && i < ret.Len(); i++ {
+			tmp, err := mapElem(source.Index(i))
+			if err != nil {
+				return err
+			}
+			ret.Index(i).Set(tmp)
+		}
+	case reflect.Slice:
+		ret = reflect.Zero(p.dest)
+		for i := 0; i < source.Len(); i++ {
+			tmp, err := mapElem(source.Index(i))
+			if err != nil {
+				return err
+			}
+			ret = reflect.Append(ret, tmp)
+		}
+	case reflect.Map:
+		ret = reflect.MakeMap(p.dest)
+		iter := source.MapRange()
+		for iter.Next() {
+			tmp, err := mapElem(iter.Value())
+			if err != nil {
+				return err
+			}
+			ret.SetMapIndex(iter.Key(), tmp)
+		}
+	}
+	dest.set(ret)
+	return nil
+}
+
+// reflectPlanner creates plans with the same rules as the generator.
+type reflectPlanner struct {
+	mapperGetter MapperGetter
+	mapping      *ReflectMapping
+}
+
+func (p *reflectPlanner) planObject(source, dest reflect.Type, prefix []int, fields []ReflectField,
+	explicitOnly bool, fromA bool) ([]*reflectStep, error) {
+	var steps []*reflectStep
+	for i := 0; i < source.NumField(); i++ {
+		name := source.Field(i).Name
+		if len(prefix) == 0 && p.mapping.ignores(fromA, name) {
+			continue
+		}
+		sourceProp, ok := findProperty(source, name, p.mapping.IgnoreCase)
+		if !ok || !sourceProp.canGet() {
+			continue
+		}
+		sourceProp.path = append(append([]int{}, prefix...), sourceProp.path...)
+
+		var explicit bool
+		for _, fm := range fields {
+			if fm.value(fromA) != name {
+				continue
+			}
+			explicit = true
+			destName := fm.value(!fromA)
+			if destName == "*" {
+				return nil, fmt.Errorf("'*' is not supported: '%s.%s'", source, name)
+			}
+			destProp, ok := findProperty(dest, destName, p.mapping.IgnoreCase)
+			if !ok || !destProp.canSet() {
+				return nil, fmt.Errorf("Could not map a field: '%s.%s' to '%s'", source, name, destName)
+			}
+			step, err := p.planStep(sourceProp, destProp, fm)
+			if err != nil {
+				return nil, err
+			}
+			if step != nil {
+				steps = append(steps, step)
+			}
+		}
+		if explicit || explicitOnly {
+			continue
+		}
+		destProp, ok := findProperty(dest, name, p.mapping.IgnoreCase)
+		if !ok || !destProp.canSet() {
+			if p.mapping.AllowUnmapped {
+				continue
+			}
+			return nil, fmt.Errorf("Unmapped field: '%s.%s'", source, name)
+		}
+		step, err := p.planStep(sourceProp, destProp, ReflectField{A: name, B: name})
+		if err != nil {
+			return nil, err
+		}
+		if step != nil {
+			steps = append(steps, step)
+		}
+	}
+
+	for _, fm := range fields {
+		parts := strings.SplitN(fm.value(fromA), ".", 2)
+		if len(parts) < 2 {
+			continue
+		}
+		f, ok := findField(source, parts[0], p.mapping.IgnoreCase)
+		if !ok || !f.IsExported() {
+			continue
+		}
+		nested := toValueType(f.Type)
+		if nested.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("%s is not a struct", f.Type)
+		}
+		nestedField := fm
+		if fromA {
+			nestedField.A = parts[1]
+		} else {
+			nestedField.B = parts[1]
+		}
+		nestedSteps, err := p.planObject(nested, dest, append(append([]int{}, prefix...), f.Index...),
+			[]ReflectField{nestedField}, true, fromA)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, nestedSteps...)
+	}
+	return steps, nil
+}
+
+func (p *reflectPlanner) planStep(source, dest *reflectProperty, fm ReflectField) (*reflectStep, error) {
+	value, err := p.planValue(source.typ, dest.typ, fm.Uses, fm.UsesForElements)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
+	}
+	return &reflectStep{
+		source: source,
+		dest:   dest,
+		value:  value,
+	}, nil
+}
+
+func (p *reflectPlanner) planValue(source, dest reflect.Type, uses, usesForElements string) (*reflectValuePlan, error) {
+	kind := source.Kind()
+	if source.Name() != "" || (kind != reflect.Array && kind != reflect.Slice && kind != reflect.Map) || uses != "" {
+		if kind == reflect.Chan && source.Name() == "" {
+			return nil, nil
+		}
+		return p.planAssign(source, dest, uses)
+	}
+	if dest.Kind() != kind || dest.Name() != "" {
+		return nil, fmt.Errorf("type mismatch: %s and %s should be a %s", source, dest, kind)
+	}
+	if kind == reflect.Map && !source.Key().AssignableTo(dest.Key()) {
+		return nil, fmt.Errorf("type mismatch: keys of %s and %s", source, dest)
+	}
+	elem, err := p.planValue(source.Elem(), dest.Elem(), usesForElements, "")
+	if err != nil {
+		return nil, err
+	}
+	if elem == nil {
+		return nil, nil
+	}
+	plan := &reflectValuePlan{
+		source: source,
+		dest:   dest,
+		elem:   elem,
+	}
+	switch kind {
+	case reflect.Map:
+		plan.nilCollection = p.mapping.NilMap
+	case reflect.Slice:
+		plan.nilCollection = p.mapping.NilSlice
+	}
+	return plan, nil
+}
+
+func (p *reflectPlanner) planAssign(source, dest reflect.Type, uses string) (*reflectValuePlan, error) {
+	plan := &reflectValuePlan{
+		source: source,
+		dest:   dest,
+	}
+	sourceName := TypeName(source)
+	destName := TypeName(dest)
+	if sourceName == destName {
+		if !canAssign(source, dest) {
+			return nil, fmt.Errorf("type mismatch: %s and %s", source, dest)
+		}
+		plan.assign = true
+		return plan, nil
+	}
+
+	if toValueType(source).Kind() == reflect.Struct && toValueType(dest).Kind() == reflect.Struct {
+		plan.mapper = p.getFunc(uses, sourceName, destName, reflect.FuncOf(
+			[]reflect.Type{contextType, reflect.PointerTo(toValueType(source)), reflect.PointerTo(toValueType(dest))},
+			[]reflect.Type{errorType}, false))
+	}
+	out := []reflect.Type{toPreferableType(dest), errorType}
+	if !isPointerPreferableType(dest) {
+		out = []reflect.Type{toPreferableType(dest), boolType, errorType}
+	}
+	plan.converter = p.getFunc(uses, sourceName, destName, reflect.FuncOf(
+		[]reflect.Type{contextType, toNillableType(source)}, out, false))
+
+	if canCast(source, dest) {
+		if !source.ConvertibleTo(dest) {
+			return nil, fmt.Errorf("type mismatch: %s can not be converted to %s", source, dest)
+		}
+		plan.cast = true
+	}
+	return plan, nil
+}
+
+// getFunc returns a function only if it has the exact same signature that
+// generated mappers use.
+func (p *reflectPlanner) getFunc(id, sourceName, destName string, typ reflect.Type) reflect.Value {
+	obj, err := p.mapperGetter.GetFuncByTypeName(id, sourceName, destName)
+	if err != nil || reflect.TypeOf(obj) != typ {
+		return reflect.Value{}
+	}
+	return reflect.ValueOf(obj)
+}
+
+func findField(typ reflect.Type, name string, ignoreCase bool) (reflect.StructField, bool) {
+	parts := strings.SplitN(name, ".", 2)
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.Name != parts[0] && (!ignoreCase || !strings.EqualFold(f.Name, parts[0])) {
+			continue
+		}
+		if len(parts) == 1 {
+			return f, true
+		}
+		nested := toValueType(f.Type)
+		if nested.Kind() != reflect.Struct {
+			return reflect.StructField{}, false
+		}
+		nf, ok := findField(nested, parts[1], ignoreCase)
+		if !ok {
+			return reflect.StructField{}, false
+		}
+		nf.Index = append(append([]int{}, f.Index...), nf.Index...)
+		return nf, true
+	}
+	return reflect.StructField{}, false
+}
+
+func findProperty(typ reflect.Type, name string, ignoreCase bool) (*reflectProperty, bool) {
+	if f, ok := findField(typ, name, ignoreCase); ok && f.IsExported() {
+		return &reflectProperty{
+			typ:  f.Type,
+			path: f.Index,
+		}, true
+	}
+	ret := &reflectProperty{}
+	ptyp := reflect.PointerTo(typ)
+	if m, ok := findMethod(ptyp, "Set"+name, ignoreCase); ok && m.Type.NumIn() == 2 {
+		ret.setter = m.Name
+		ret.typ = m.Type.In(1)
+	}
+	if m, ok := findMethod(ptyp, name, ignoreCase); ok && m.Type.NumIn() == 1 && m.Type.NumOut() != 0 {
+		ret.getter = m.Name
+		ret.typ = m.Type.Out(0)
+	}
+	if ret.getter == "" && ret.setter == "" {
+		return nil, false
+	}
+	return ret, true
+}
+
+func findMethod(typ reflect.Type, name string, ignoreCase bool) (reflect.Method, bool) {
+	for i := 0; i < typ.NumMethod(); i++ {
+		m := typ.Method(i)
+		if m.Name == name || (ignoreCase && strings.EqualFold(m.Name, name)) {
+			return m, true
+		}
+	}
+	return reflect.Method{}, false
+}
+
+func addrOf(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v.Addr()
+	}
+	return copyOf(v).Addr()
+}
+
+func copyOf(v reflect.Value) reflect.Value {
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	return c
+}
+
+func toValueType(typ reflect.Type) reflect.Type {
+	if typ.Kind() == reflect.Ptr {
+		return typ.Elem()
+	}
+	return typ
+}
+
+func isBasicKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128, reflect.String,
+		reflect.UnsafePointer:
+		return true
+	}
+	return false
+}
+
+func isNillableType(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Slice, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// isPointerPreferableType returns false if typ is a basic type, an unnamed
+// interface or an unnamed array.
+func isPointerPreferableType(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if isBasicKind(typ.Kind()) {
+		return false
+	}
+	if (typ.Kind() == reflect.Interface || typ.Kind() == reflect.Array) && typ.Name() == "" {
+		return false
+	}
+	return true
+}
+
+func isNamedInterface(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Interface && typ.Name() != ""
+}
+
+func isUnnamedCollection(typ reflect.Type) bool {
+	return (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map) && typ.Name() == ""
+}
+
+// toNillableType returns a type of sources of converter functions.
+func toNillableType(typ reflect.Type) reflect.Type {
+	if isNamedInterface(typ) || typ.Kind() == reflect.Ptr || isUnnamedCollection(typ) {
+		return typ
+	}
+	return reflect.PointerTo(typ)
+}
+
+// toPreferableType returns a type of results of converter functions.
+func toPreferableType(typ reflect.Type) reflect.Type {
+	if isNamedInterface(typ) {
+		return typ
+	}
+	if typ.Kind() == reflect.Ptr {
+		if !isPointerPreferableType(typ) {
+			return typ.Elem()
+		}
+	} else if isPointerPreferableType(typ) && !isUnnamedCollection(typ) {
+		return reflect.PointerTo(typ)
+	}
+	return typ
+}
+
+func canAssign(source, dest reflect.Type) bool {
+	sourceIsNillable := isNillableType(source)
+	destIsNillable := isNillableType(dest)
+	switch {
+	case sourceIsNillable && !destIsNillable:
+		return source.Kind() == reflect.Ptr && source.Elem().AssignableTo(dest)
+	case !sourceIsNillable && destIsNillable:
+		return reflect.PointerTo(source).AssignableTo(dest)
+	}
+	return source.AssignableTo(dest)
+}
+
+func toUnderlyingTypeName(typ reflect.Type) string {
+	typ = toValueType(typ)
+	switch {
+	case isBasicKind(typ.Kind()):
+		return typ.Kind().String()
+	case typ.Kind() == reflect.Slice:
+		return toTypeNameAux(reflect.SliceOf(typ.Elem()))
+	case typ.Kind() == reflect.Array:
+		return toTypeNameAux(reflect.ArrayOf(typ.Len(), typ.Elem()))
+	case typ.Kind() == reflect.Map:
+		return toTypeNameAux(reflect.MapOf(typ.Key(), typ.Elem()))
+	}
+	return toTypeNameAux(typ)
+}
+
+// canCast returns true if generated mappers cast source to dest.
+func canCast(source, dest reflect.Type) bool {
+	if TypeName(source) == toUnderlyingTypeName(dest) || TypeName(dest) == toUnderlyingTypeName(source) {
+		return true
+	}
+	// A small size integer can be casted into a large size integer
+	if source.PkgPath() != "" || dest.PkgPath() != "" ||
+		!isBasicKind(source.Kind()) || !isBasicKind(dest.Kind()) {
+		return false
+	}
+	sname := to32(source.Name())
+	dname := to32(dest.Name())
+	if strings.HasPrefix(sname, "int") && strings.HasPrefix(dname, "int") {
+		sbit, _ := strconv.Atoi(sname[3:])
+		dbit, _ := strconv.Atoi(dname[3:])
+		return dbit > sbit
+	}
+	if strings.HasPrefix(sname, "uint") && strings.HasPrefix(dname, "uint") {
+		sbit, _ := strconv.Atoi(sname[4:])
+		dbit, _ := strconv.Atoi(dname[4:])
+		return dbit < sbit
+	}
+	return false
+}
+
+func to32(t string) string {
+	if t == "int" {
+		return "int32"
+	}
+	if t == "uint" {
+		return "uint32"
+	}
+	return t
+}
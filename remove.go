@@ -0,0 +1,106 @@
+package sesame
+
+import (
+	"slices"
+)
+
+func (d *mappers) Remove(id string) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.Frozen() {
+		return newFrozenError(id, "remove %s", id)
+	}
+	return d.remove(id)
+}
+
+func (d *mappers) Replace(id string, obj any, opts ...AddOption) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.Frozen() {
+		return newFrozenError(id, "replace %s", id)
+	}
+	if !d.has(id) {
+		return newError(ErrNotFound, id, "object %s not found", id)
+	}
+	// add removes the old object after functions of obj are checked, so
+	// that a failed Replace leaves this mappers unchanged.
+	return d.add(id, obj, opts...)
+}
+
+// has returns true if this mappers has an object or a factory with given id.
+func (d *mappers) has(id string) bool {
+	_, dok := d.dependencies.Load(id)
+	_, fok := d.factories.Load(id)
+	return dok || fok
+}
+
+// remove removes an object, a factory and functions with given id.
+// remove also invalidates objects created by factories that depend on
+// the object, so that they are created again on the next Get.
+func (d *mappers) remove(id string) error {
+	if !d.has(id) {
+		return newError(ErrNotFound, id, "object %s not found", id)
+	}
+	d.dependencies.Delete(id)
+	d.factories.Delete(id)
+	d.forgetScoped(id)
+	d.findex.Range(func(k, v any) bool {
+		lst := v.([]mfunc)
+		if !slices.ContainsFunc(lst, func(f mfunc) bool { return f.AddedBy == id }) {
+			return true
+		}
+		lst = slices.DeleteFunc(slices.Clone(lst), func(f mfunc) bool { return f.AddedBy == id })
+		if len(lst) == 0 {
+			d.findex.Delete(k)
+		} else {
+			d.findex.Store(k, lst)
+		}
+		return true
+	})
+	for _, dependent := range d.dependents(id) {
+		// Children create objects by factories of parents too.
+		if d.lookupFactory(dependent) != nil {
+			d.dependencies.Delete(dependent)
+			d.forgetScoped(dependent)
+		}
+		d.removeEdges(dependent)
+	}
+	d.removeEdges(id)
+	d.generation.Add(1)
+	return nil
+}
+
+// dependents returns ids that depend on given id directly or indirectly.
+func (d *mappers) dependents(id string) []string {
+	graph := map[string][]string{}
+	d.edges.Range(func(k, _ any) bool {
+		e := k.(dependencyEdge)
+		graph[e.to] = append(graph[e.to], e.from)
+		return true
+	})
+	var ret []string
+	visited := map[string]bool{id: true}
+	queue := []string{id}
+	for len(queue) != 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, from := range graph[cur] {
+			if !visited[from] {
+				visited[from] = true
+				ret = append(ret, from)
+				queue = append(queue, from)
+			}
+		}
+	}
+	return ret
+}
+
+// removeEdges removes edges from given id.
+func (d *mappers) removeEdges(from string) {
+	d.edges.Range(func(k, _ any) bool {
+		if k.(dependencyEdge).from == from {
+			d.edges.Delete(k)
+		}
+		return true
+	})
+}
@@ -0,0 +1,101 @@
+package sesame
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// resolver is a [MapperGetter] that is passed to factories.
+// resolver tracks ids that are being created to detect cyclic dependencies.
+// resolver is also returned by [Mappers.ForContext] .
+type resolver struct {
+	mappers *mappers
+	ctx     context.Context
+	chain   []string
+}
+
+func (r *resolver) Get(id string) (any, error) {
+	return r.mappers.get(r.ctx, id, r.chain)
+}
+
+func (r *resolver) GetAllMappers() (map[string]any, error) {
+	return r.mappers.getAllMappers(r.ctx, r.chain)
+}
+
+func (r *resolver) GetFunc(id string, sourceType, destType reflect.Type) (any, error) {
+	return r.mappers.getFuncByTypeName(r.ctx, id, TypeName(sourceType), TypeName(destType), r.chain)
+}
+
+func (r *resolver) GetFuncByTypeName(id string, sourceName, destName string) (any, error) {
+	return r.mappers.getFuncByTypeName(r.ctx, id, sourceName, destName, r.chain)
+}
+
+func newCycleError(chain []string) error {
+	return newError(ErrCycle, chain[len(chain)-1], "cyclic dependency detected: %s", strings.Join(chain, " -> "))
+}
+
+type dependencyEdge struct {
+	from string
+	to   string
+}
+
+func (d *mappers) addEdge(from, to string) {
+	e := dependencyEdge{from: from, to: to}
+	if _, ok := d.edges.Load(e); !ok {
+		d.edges.Store(e, struct{}{})
+	}
+}
+
+func (d *mappers) DependencyGraph() map[string][]string {
+	graph := map[string][]string{}
+	d.edges.Range(func(k, _ any) bool {
+		e := k.(dependencyEdge)
+		graph[e.from] = append(graph[e.from], e.to)
+		return true
+	})
+	for _, deps := range graph {
+		sort.Strings(deps)
+	}
+	return graph
+}
+
+// beginWait records that the creation of parent waits for the creation of id.
+// Factories for different ids run concurrently, so a cycle may be split
+// across goroutines. beginWait follows waits from id and returns an error
+// if they reach an id that is being created in chain.
+func (d *mappers) beginWait(parent, id string, chain []string) error {
+	if len(parent) == 0 {
+		return nil
+	}
+	d.waitsLock.Lock()
+	defer d.waitsLock.Unlock()
+	if d.waits == nil {
+		d.waits = map[string]string{}
+	}
+	path := []string{id}
+	for cur := id; len(path) <= len(d.waits)+1; {
+		next, ok := d.waits[cur]
+		if !ok {
+			break
+		}
+		path = append(path, next)
+		if i := slices.Index(chain, next); i >= 0 {
+			return newCycleError(append(slices.Clone(chain), path...))
+		}
+		cur = next
+	}
+	d.waits[parent] = id
+	return nil
+}
+
+func (d *mappers) endWait(parent string) {
+	if len(parent) == 0 {
+		return
+	}
+	d.waitsLock.Lock()
+	defer d.waitsLock.Unlock()
+	delete(d.waits, parent)
+}
@@ -1,30 +1,41 @@
 package sesame_test
 
 import (
+	"encoding/json"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 )
 
-func TestRun(t *testing.T) {
+func buildExecutable(t *testing.T) string {
 	executable := "sesame"
 	if runtime.GOOS == "windows" {
 		executable += ".exe"
 	}
 
 	cmd := exec.Command("go", "build", "-o", executable, "./cmd/sesame")
-	defer os.Remove(executable)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		t.Fatal(err.Error() + ":" + string(out))
 	}
+	t.Cleanup(func() {
+		os.Remove(executable)
+	})
+	abs, _ := filepath.Abs(executable)
+	return abs
+}
+
+func TestRun(t *testing.T) {
+	executable := buildExecutable(t)
 
 	pwd, _ := os.Getwd()
 	defer os.Chdir(pwd)
 	os.Chdir("./testdata/testmod")
-	cmd = exec.Command("../../" + executable)
-	out, err = cmd.CombinedOutput()
+	cmd := exec.Command(executable)
+	out, err := cmd.CombinedOutput()
 	if err != nil {
 		t.Fatal(err.Error() + ":" + string(out))
 	}
@@ -35,3 +46,136 @@ func TestRun(t *testing.T) {
 	}
 	t.Log(string(out))
 }
+
+func TestConfigErrorPositions(t *testing.T) {
+	executable := buildExecutable(t)
+
+	cmd := exec.Command(executable, "-c", "./testdata/invalid/sesame.yml")
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatal("sesame should fail with an invalid configuration")
+	}
+	expected := []string{
+		filepath.Join("testdata", "invalid", "sesame.yml") + ":11:7:\t$.mappings[0].b.name must not be empty",
+		filepath.Join("testdata", "invalid", "sesame.yml") + ":13:9:\t$.mappings[0].fields[0].b must not be empty",
+	}
+	for _, e := range expected {
+		if !strings.Contains(string(out), e) {
+			t.Errorf("%q should contain %q", string(out), e)
+		}
+	}
+}
+
+func TestUnknownFieldSuggestions(t *testing.T) {
+	executable := buildExecutable(t)
+
+	cmd := exec.Command(executable, "-c", "./testdata/testmod/invalid/unknown_fields.yml")
+	defer os.Remove("./testdata/testmod/invalid/todo_mapper_gen.go")
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatal("sesame should fail with unknown fields")
+	}
+	expected := []string{
+		"unknown_fields.yml:15:9:\tUnknown field 'Finishd' in domain.Todo, did you mean 'Finished'?",
+		"unknown_fields.yml:17:9:\tUnknown field 'Adress' in domain.User (referenced by 'User.Adress'), " +
+			"did you mean 'Address'?",
+		"unknown_fields.yml:19:9:\tUnknown field 'ValidatOnly' in model.TodoModel, did you mean 'ValidateOnly'?",
+	}
+	for _, e := range expected {
+		if !strings.Contains(string(out), e) {
+			t.Errorf("%q should contain %q", string(out), e)
+		}
+	}
+}
+
+func TestStaticWiringUnresolvedFunction(t *testing.T) {
+	executable := buildExecutable(t)
+
+	cmd := exec.Command(executable, "-c", "./testdata/testmod/invalid/static_wiring.yml")
+	defer os.Remove("./testdata/testmod/invalid/user_mapper_gen.go")
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatal("sesame should fail with a converter that has no suitable method")
+	}
+	expected := "static_wiring.yml:18:9:\t../mapper.StreetConverter has no method that maps string -> time#Time"
+	if !strings.Contains(string(out), expected) {
+		t.Errorf("%q should contain %q", string(out), expected)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	executable := buildExecutable(t)
+
+	cmd := exec.Command(executable, "check", "-q", "-c", "./testdata/testmod/invalid/dead_entries.yml")
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatal("sesame check should fail with dead entries")
+	}
+	expected := []string{
+		"dead_entries.yml:15:9:\tField mapping 'Name' to 'Name' is identical to an implicit mapping",
+		"dead_entries.yml:19:9:\tConverter UnregisteredConverter is referenced nowhere in code",
+		"dead_entries.yml:21:9:\tIgnored field no longer exists: Unknown field 'Removed' in model.UserModel",
+		"dead_entries.yml:23:5:\tDestination ",
+		"4 problem(s) found",
+	}
+	for _, e := range expected {
+		if !strings.Contains(string(out), e) {
+			t.Errorf("%q should contain %q", string(out), e)
+		}
+	}
+}
+
+func TestDiagnosticsFormat(t *testing.T) {
+	executable := buildExecutable(t)
+
+	cmd := exec.Command(executable, "check", "-format", "json", "-c", "./testdata/testmod/invalid/dead_entries.yml")
+	out, err := cmd.Output()
+	if err == nil {
+		t.Fatal("sesame check should fail with dead entries")
+	}
+	var diags []struct {
+		Severity       string `json:"severity"`
+		Code           string `json:"code"`
+		Message        string `json:"message"`
+		ConfigLocation struct {
+			File   string `json:"file"`
+			Line   int    `json:"line"`
+			Column int    `json:"column"`
+		} `json:"configLocation"`
+		GoLocation *struct {
+			File string `json:"file"`
+		} `json:"goLocation"`
+	}
+	if err := json.Unmarshal(out, &diags); err != nil {
+		t.Fatalf("stdout should be a JSON: %v: %s", err, string(out))
+	}
+	if len(diags) != 4 {
+		t.Fatalf("4 diagnostics expected, but got %d", len(diags))
+	}
+	ignore := diags[2]
+	if ignore.Severity != "warning" || ignore.Code != "unused-ignore" ||
+		ignore.ConfigLocation.Line != 21 || ignore.ConfigLocation.Column != 9 {
+		t.Errorf("unexpected diagnostic: %+v", ignore)
+	}
+	if ignore.GoLocation == nil || !strings.HasSuffix(ignore.GoLocation.File, "user.go") {
+		t.Errorf("diagnostic should have a Go location: %+v", ignore)
+	}
+
+	cmd = exec.Command(executable, "check", "-format", "sarif", "-c", "./testdata/testmod/invalid/dead_entries.yml")
+	out, _ = cmd.Output()
+	var sarif struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID string `json:"ruleId"`
+				Level  string `json:"level"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(out, &sarif); err != nil {
+		t.Fatalf("stdout should be a SARIF log: %v: %s", err, string(out))
+	}
+	if sarif.Version != "2.1.0" || len(sarif.Runs) != 1 || len(sarif.Runs[0].Results) != 4 {
+		t.Errorf("unexpected SARIF log: %s", string(out))
+	}
+}
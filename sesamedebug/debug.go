@@ -0,0 +1,41 @@
+// Package sesamedebug provides views of [sesame.Mappers] for debugging.
+package sesamedebug
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+
+	"github.com/yuin/sesame"
+)
+
+// Handler returns an [http.Handler] that serves [sesame.Mappers.Describe]
+// as JSON.
+// Query parameters 'source' and 'dest' filter functions by type names:
+//
+//	http.Handle("/debug/sesame", sesamedebug.Handler(mappers))
+//	// GET /debug/sesame?source=time%23Time&dest=string
+func Handler(mappers sesame.Mappers) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		desc := mappers.Describe()
+		source, dest := r.URL.Query().Get("source"), r.URL.Query().Get("dest")
+		if len(source) != 0 || len(dest) != 0 {
+			desc.Functions = desc.FunctionsFor(source, dest)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(desc); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// Publish publishes [sesame.Mappers.Describe] as an expvar variable with
+// given name. Like [expvar.Publish] , Publish panics if the name is
+// already registered.
+func Publish(name string, mappers sesame.Mappers) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return mappers.Describe()
+	}))
+}
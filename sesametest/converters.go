@@ -0,0 +1,117 @@
+package sesametest
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/yuin/sesame"
+)
+
+// TimeStringConverter converts strings to/from times.
+// Times are formatted in Location, so results do not depend on
+// the local time zone.
+type TimeStringConverter struct {
+	// Layout is a layout of strings. Defaults to time.RFC3339 .
+	Layout string
+
+	// Location is a location of times. Defaults to time.UTC .
+	Location *time.Location
+}
+
+func (c *TimeStringConverter) layout() string {
+	if len(c.Layout) == 0 {
+		return time.RFC3339
+	}
+	return c.Layout
+}
+
+func (c *TimeStringConverter) location() *time.Location {
+	if c.Location == nil {
+		return time.UTC
+	}
+	return c.Location
+}
+
+// StringToTime converts a string to a time.
+func (c *TimeStringConverter) StringToTime(ctx context.Context, source *string) (*time.Time, error) {
+	if source == nil {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation(c.layout(), *source, c.location())
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// TimeToString converts a time to a string.
+func (c *TimeStringConverter) TimeToString(ctx context.Context, source *time.Time) (string, bool, error) {
+	if source == nil {
+		return "", true, nil
+	}
+	return source.In(c.location()).Format(c.layout()), false, nil
+}
+
+// FixedTimeStringConverter is a [TimeStringConverter] that always returns
+// Time. This is useful for fields like 'CreatedAt' .
+type FixedTimeStringConverter struct {
+	// Time is a time returned by this converter.
+	Time time.Time
+
+	// Layout is a layout of strings. Defaults to time.RFC3339 .
+	Layout string
+}
+
+// StringToTime returns a fixed time.
+func (c *FixedTimeStringConverter) StringToTime(ctx context.Context, source *string) (*time.Time, error) {
+	t := c.Time
+	return &t, nil
+}
+
+// TimeToString returns a fixed time as a string.
+func (c *FixedTimeStringConverter) TimeToString(ctx context.Context, source *time.Time) (string, bool, error) {
+	return (&TimeStringConverter{Layout: c.Layout, Location: c.Time.Location()}).TimeToString(ctx, &c.Time)
+}
+
+// IntStringConverter converts strings to/from ints.
+type IntStringConverter struct {
+}
+
+// StringToInt converts a string to an int.
+func (c *IntStringConverter) StringToInt(ctx context.Context, source *string) (int, bool, error) {
+	if source == nil {
+		return 0, true, nil
+	}
+	i, err := strconv.Atoi(*source)
+	if err != nil {
+		return 0, false, err
+	}
+	return i, false, nil
+}
+
+// IntToString converts an int to a string.
+func (c *IntStringConverter) IntToString(ctx context.Context, source *int) (string, bool, error) {
+	if source == nil {
+		return "", true, nil
+	}
+	return strconv.Itoa(*source), false, nil
+}
+
+// FixedTime is a time that can be used with [FixedTimeStringConverter] .
+var FixedTime = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// AddConverters adds deterministic converters to mappers:
+//
+//   - TimeStringConverter: RFC3339 in UTC
+//   - IntStringConverter
+//
+// [FixedTimeStringConverter] is not added because it has the same types
+// as TimeStringConverter. Add it with an id used by your mappers.
+// AddConverters returns an error if mappers is frozen.
+func AddConverters(mappers sesame.Mappers) error {
+	if err := mappers.Add("TimeStringConverter", &TimeStringConverter{}); err != nil {
+		return err
+	}
+	return mappers.Add("IntStringConverter", &IntStringConverter{})
+}
@@ -0,0 +1,137 @@
+package sesametest
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/yuin/sesame"
+)
+
+// Call is a recorded call of a [sesame.MapperGetter] method.
+type Call struct {
+	// Method is a name of the called method like 'Get' and 'GetFunc' .
+	Method string
+
+	// ID is an id passed to the method.
+	ID string
+
+	// SourceType is a name of a source type passed to GetFunc and
+	// GetFuncByTypeName like 'time#Time' .
+	SourceType string
+
+	// DestType is a name of a dest type passed to GetFunc and
+	// GetFuncByTypeName like 'time#Time' .
+	DestType string
+
+	// Err is an error returned by the method.
+	Err error
+}
+
+// Recorder is a [sesame.Mappers] that records every Get, GetAllMappers,
+// GetFunc and GetFuncByTypeName call.
+// Lookups by factories added through the Recorder are also recorded.
+//
+//	rec := sesametest.NewRecorder(sesame.NewMappers())
+//	userMapper := mapper.NewUserMapper(rec)
+//	// rec.Calls() returns converters and mappers the UserMapper looked up.
+type Recorder struct {
+	sesame.Mappers
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+// NewRecorder returns a new [Recorder] that delegates to given mappers.
+func NewRecorder(mappers sesame.Mappers) *Recorder {
+	return &Recorder{
+		Mappers: mappers,
+	}
+}
+
+// Calls returns recorded calls in the order they are called.
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call{}, r.calls...)
+}
+
+// Reset clears recorded calls.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+func (r *Recorder) record(c Call) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, c)
+}
+
+// Get implements [sesame.MapperGetter] .
+func (r *Recorder) Get(id string) (any, error) {
+	return r.getter().Get(id)
+}
+
+// GetAllMappers implements [sesame.MapperGetter] .
+func (r *Recorder) GetAllMappers() (map[string]any, error) {
+	return r.getter().GetAllMappers()
+}
+
+// GetFunc implements [sesame.MapperGetter] .
+func (r *Recorder) GetFunc(id string, sourceType reflect.Type, destType reflect.Type) (any, error) {
+	return r.getter().GetFunc(id, sourceType, destType)
+}
+
+// GetFuncByTypeName implements [sesame.MapperGetter] .
+func (r *Recorder) GetFuncByTypeName(id string, sourceName string, destName string) (any, error) {
+	return r.getter().GetFuncByTypeName(id, sourceName, destName)
+}
+
+// ForContext implements [sesame.Mappers] . Lookups by the returned getter are recorded.
+func (r *Recorder) ForContext(ctx context.Context) sesame.MapperGetter {
+	return &recordingGetter{MapperGetter: r.Mappers.ForContext(ctx), recorder: r}
+}
+
+func (r *Recorder) getter() *recordingGetter {
+	return &recordingGetter{MapperGetter: r.Mappers, recorder: r}
+}
+
+// AddFactory implements [sesame.Mappers] . Lookups by the factory are recorded.
+func (r *Recorder) AddFactory(id string, typ reflect.Type, factory func(sesame.MapperGetter) (any, error),
+	opts ...sesame.AddOption) error {
+	return r.Mappers.AddFactory(id, typ, func(mg sesame.MapperGetter) (any, error) {
+		return factory(&recordingGetter{MapperGetter: mg, recorder: r})
+	}, opts...)
+}
+
+// recordingGetter records calls to a [sesame.MapperGetter] .
+type recordingGetter struct {
+	sesame.MapperGetter
+	recorder *Recorder
+}
+
+func (g *recordingGetter) Get(id string) (any, error) {
+	obj, err := g.MapperGetter.Get(id)
+	g.recorder.record(Call{Method: "Get", ID: id, Err: err})
+	return obj, err
+}
+
+func (g *recordingGetter) GetAllMappers() (map[string]any, error) {
+	ms, err := g.MapperGetter.GetAllMappers()
+	g.recorder.record(Call{Method: "GetAllMappers", Err: err})
+	return ms, err
+}
+
+func (g *recordingGetter) GetFunc(id string, sourceType reflect.Type, destType reflect.Type) (any, error) {
+	f, err := g.MapperGetter.GetFunc(id, sourceType, destType)
+	g.recorder.record(Call{Method: "GetFunc", ID: id, SourceType: sesame.TypeName(sourceType), DestType: sesame.TypeName(destType), Err: err})
+	return f, err
+}
+
+func (g *recordingGetter) GetFuncByTypeName(id string, sourceName string, destName string) (any, error) {
+	f, err := g.MapperGetter.GetFuncByTypeName(id, sourceName, destName)
+	g.recorder.record(Call{Method: "GetFuncByTypeName", ID: id, SourceType: sourceName, DestType: destName, Err: err})
+	return f, err
+}
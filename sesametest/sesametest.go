@@ -0,0 +1,159 @@
+// Package sesametest provides utilities for testing mappers.
+package sesametest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/yuin/sesame"
+)
+
+type roundTripOptions struct {
+	Context      context.Context
+	IgnoreFields []string
+	Diff         func(expected, actual any) string
+}
+
+// RoundTripOption is a type for options of [AssertRoundTrip] .
+type RoundTripOption func(*roundTripOptions)
+
+// WithContext sets a context passed to mapper functions.
+func WithContext(ctx context.Context) RoundTripOption {
+	return func(o *roundTripOptions) {
+		o.Context = ctx
+	}
+}
+
+// WithIgnoreFields ignores given fields of A. This is useful for fields
+// that are not mapped in either direction.
+func WithIgnoreFields(names ...string) RoundTripOption {
+	return func(o *roundTripOptions) {
+		o.IgnoreFields = append(o.IgnoreFields, names...)
+	}
+}
+
+// WithDiff sets a function that compares objects. diff must return an
+// empty string if objects are equal. By default, objects are compared by
+// [reflect.DeepEqual] . diff can be used with go-cmp:
+//
+//	sesametest.WithDiff(func(x, y any) string {
+//		return cmp.Diff(x, y, cmpopts.IgnoreUnexported(model.TodoModel{}))
+//	})
+func WithDiff(diff func(expected, actual any) string) RoundTripOption {
+	return func(o *roundTripOptions) {
+		o.Diff = diff
+	}
+}
+
+// AssertRoundTrip maps a to B and maps it back to A by a bidirectional mapper,
+// and then asserts that the result is equal to a.
+// mapper must have both functions for A -> B and B -> A like generated
+// bidirectional mappers.
+//
+//	obj, _ := mappers.Get("TodoMapper")
+//	sesametest.AssertRoundTrip(t, obj, todoModel, sesametest.WithIgnoreFields("ValidateOnly"))
+func AssertRoundTrip[A any](t testing.TB, mapper any, a *A, opts ...RoundTripOption) {
+	t.Helper()
+	options := &roundTripOptions{
+		Context: context.Background(),
+		Diff:    deepEqualDiff,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	aToB, bToA, ok := findRoundTripFuncs(mapper, reflect.TypeOf(a))
+	if !ok {
+		t.Errorf("%T does not have mapper functions for %T <-> B", mapper, a)
+		return
+	}
+	ctx := reflect.ValueOf(options.Context)
+	b := reflect.New(aToB.Type().In(2).Elem())
+	if err := call(aToB, ctx, reflect.ValueOf(a), b); err != nil {
+		t.Errorf("failed to map %T to %s: %v", a, b.Type(), err)
+		return
+	}
+	back := new(A)
+	if err := call(bToA, ctx, b, reflect.ValueOf(back)); err != nil {
+		t.Errorf("failed to map %s to %T: %v", b.Type(), a, err)
+		return
+	}
+	expected, actual := *a, *back
+	for _, name := range options.IgnoreFields {
+		for _, v := range []reflect.Value{reflect.ValueOf(&expected).Elem(), reflect.ValueOf(&actual).Elem()} {
+			f := v.FieldByName(name)
+			if !f.IsValid() || !f.CanSet() {
+				t.Errorf("%T does not have an exported field %s", a, name)
+				return
+			}
+			f.Set(reflect.Zero(f.Type()))
+		}
+	}
+	if diff := options.Diff(&expected, &actual); len(diff) != 0 {
+		t.Errorf("%T is changed by a round trip via %s:\n%s", a, b.Type(), diff)
+	}
+}
+
+func findRoundTripFuncs(mapper any, typ reflect.Type) (reflect.Value, reflect.Value, bool) {
+	v := reflect.ValueOf(mapper)
+	for i := 0; i < v.NumMethod(); i++ {
+		aToB := v.Method(i)
+		ft := aToB.Type()
+		if !isMapperFunc(ft) || ft.In(1) != typ || ft.In(2).Kind() != reflect.Ptr {
+			continue
+		}
+		for j := 0; j < v.NumMethod(); j++ {
+			bToA := v.Method(j)
+			bt := bToA.Type()
+			if isMapperFunc(bt) && bt.In(1) == ft.In(2) && bt.In(2) == typ {
+				return aToB, bToA, true
+			}
+		}
+	}
+	return reflect.Value{}, reflect.Value{}, false
+}
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+func isMapperFunc(ft reflect.Type) bool {
+	return ft.NumIn() == 3 && ft.In(0) == contextType && ft.NumOut() == 1 && ft.Out(0) == errorType
+}
+
+func call(f reflect.Value, args ...reflect.Value) error {
+	if err := f.Call(args)[0]; !err.IsNil() {
+		return err.Interface().(error)
+	}
+	return nil
+}
+
+func deepEqualDiff(expected, actual any) string {
+	if reflect.DeepEqual(expected, actual) {
+		return ""
+	}
+	return fmt.Sprintf("expected: %+v\nactual:   %+v", expected, actual)
+}
+
+// AssertAllConvertersRegistered asserts that all converters, mappers and
+// helpers required by generated mappers are registered.
+// See [sesame.Mappers.Validate] .
+func AssertAllConvertersRegistered(t testing.TB, mappers sesame.Mappers) {
+	t.Helper()
+	err := mappers.Validate()
+	if err == nil {
+		return
+	}
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+	for _, e := range errs {
+		if errors.Is(e, sesame.ErrNotFound) {
+			t.Errorf("not registered: %v", e)
+		} else {
+			t.Errorf("%v", e)
+		}
+	}
+}
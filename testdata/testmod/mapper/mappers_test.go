@@ -1,9 +1,21 @@
 package mapper_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"example.com/testmod/domain"
 	"example.com/testmod/mapper"
@@ -11,6 +23,7 @@ import (
 	"example.com/testmod/model"
 	"github.com/google/go-cmp/cmp"
 	"github.com/yuin/sesame"
+	"github.com/yuin/sesame/sesamedebug"
 )
 
 func TestMappersGetFunc(t *testing.T) {
@@ -46,3 +59,1205 @@ func TestMappersGetFunc(t *testing.T) {
 	}
 
 }
+
+func TestMappersValidate(t *testing.T) {
+	mappers := NewMappers()
+	err := mappers.Validate()
+	if err == nil {
+		t.Fatal("Validate should fail without converters")
+	}
+	var se *sesame.Error
+	if !errors.As(err, &se) || !se.NotFound() {
+		t.Errorf("Validate should return not found errors: %v", err)
+	}
+	expected := "sesame.yml:28:9:\ttestdata.TodoMapper requires FixedTimeStringConverter for string -> time#Time"
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("%q should contain %q", err.Error(), expected)
+	}
+
+	mapper.AddTimeToStringConverter(mappers)
+	mapper.AddInfToStringConverter(mappers)
+	mapper.AddStreetConverter(mappers)
+	mapper.AddIntStringConverter(mappers)
+	mapper.AddDate1Converter(mappers)
+	mapper.AddPrioritiesConverter(mappers)
+	err = mappers.Validate()
+	if err == nil {
+		t.Fatal("Validate should fail without an int64 to int converter")
+	}
+	if strings.Contains(err.Error(), "FixedTimeStringConverter") {
+		t.Errorf("FixedTimeStringConverter should be resolved: %v", err)
+	}
+	expected = "testdata.TodoMapper requires a global function for int64 -> int"
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("%q should contain %q", err.Error(), expected)
+	}
+
+	// Validate reports all problems including PerContext mappers.
+	mappers = sesame.NewMappers()
+	sesame.AddFactory(mappers, "BrokenMapper", func(mg sesame.MapperGetter) (UserMapper, error) {
+		return nil, errors.New("broken")
+	})
+	sesame.AddFactory(mappers, UserMapperID, func(mg sesame.MapperGetter) (UserMapper, error) {
+		return NewUserMapper(mg), nil
+	}, sesame.WithLifecycle(sesame.PerContext), sesame.WithContextKey(tenantKey{}))
+	err = mappers.Validate()
+	for _, expected := range []string{
+		"BrokenMapper: failed to create a mapper: broken",
+		"UserMapper requires a global function for string -> time#Time",
+	} {
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%v should contain %q", err, expected)
+		}
+	}
+}
+
+func TestGeneratedMappers(t *testing.T) {
+	mappers := NewGeneratedMappers()
+	mapper.AddTimeToStringConverter(mappers)
+	ctx := context.TODO()
+
+	userMapper, err := mappers.UserMapper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entity domain.User
+	if err := userMapper.UserModelToUser(ctx, &model.UserModel{ID: "id1", UpdatedAt: "2024-07-18T10:15:36Z"}, &entity); err != nil {
+		t.Fatal(err)
+	}
+	if entity.ID != "id1" {
+		t.Errorf("unexpected result: %+v", entity)
+	}
+
+	todoMapper, err := mappers.TodoMapper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj, err := mappers.Get(TodoMapperID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj != todoMapper {
+		t.Error("TodoMapper should return the mapper registered as TodoMapperID")
+	}
+}
+
+func TestMappersConcurrentGet(t *testing.T) {
+	mappers := sesame.NewMappers()
+	var created atomic.Int32
+	slowStarted := make(chan struct{})
+	releaseSlow := make(chan struct{})
+	sesame.AddFactory(mappers, "SlowConverter", func(mg sesame.MapperGetter) (*TimeStringConverter, error) {
+		created.Add(1)
+		close(slowStarted)
+		<-releaseSlow
+		return &TimeStringConverter{}, nil
+	})
+	sesame.AddFactory(mappers, "FastConverter", func(mg sesame.MapperGetter) (*TimeStringConverter, error) {
+		return &TimeStringConverter{}, nil
+	})
+
+	var wg sync.WaitGroup
+	results := make([]any, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			obj, err := mappers.Get("SlowConverter")
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = obj
+		}(i)
+	}
+
+	<-slowStarted
+	// A slow factory must not block other ids.
+	if _, err := mappers.Get("FastConverter"); err != nil {
+		t.Fatal(err)
+	}
+	close(releaseSlow)
+	wg.Wait()
+
+	if n := created.Load(); n != 1 {
+		t.Errorf("factory should be called once, but called %d times", n)
+	}
+	for _, obj := range results {
+		if obj != results[0] {
+			t.Error("all goroutines should get the same object")
+		}
+	}
+}
+
+func TestMappersFreeze(t *testing.T) {
+	mappers := NewMappers()
+	if err := mappers.Add("TimeStringConverter", &TimeStringConverter{}); err != nil {
+		t.Fatal(err)
+	}
+	mappers.Freeze()
+	if !mappers.Frozen() {
+		t.Error("mappers should be frozen")
+	}
+
+	var se *sesame.Error
+	err := mappers.Add("InfStringConverter", &InfStringConverter{})
+	if !errors.As(err, &se) || !se.Frozen() {
+		t.Errorf("Add should fail with a frozen error: %v", err)
+	}
+	err = sesame.AddFactory(mappers, "FixedTimeStringConverter",
+		func(mg sesame.MapperGetter) (*FixedTimeStringConverter, error) {
+			return &FixedTimeStringConverter{}, nil
+		})
+	if !errors.As(err, &se) || !se.Frozen() {
+		t.Errorf("AddFactory should fail with a frozen error: %v", err)
+	}
+	err = mappers.Merge(sesame.NewMappers())
+	if !errors.As(err, &se) || !se.Frozen() {
+		t.Errorf("Merge should fail with a frozen error: %v", err)
+	}
+
+	// Get actions are still available.
+	if _, err := mappers.Get("TimeStringConverter"); err != nil {
+		t.Error(err)
+	}
+	if _, err := mappers.Get("InfStringConverter"); err == nil {
+		t.Error("InfStringConverter should not be added")
+	}
+}
+
+func TestMappersConcurrentAdd(t *testing.T) {
+	mappers := sesame.NewMappers()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := mappers.Add(fmt.Sprintf("TimeStringConverter%d", i), &TimeStringConverter{}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i := 0; i < 10; i++ {
+		if _, err := mappers.Get(fmt.Sprintf("TimeStringConverter%d", i)); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	mappers, err := sesame.NewBuilder().
+		Merge(NewMappers()).
+		Configure(mapper.AddTimeToStringConverter).
+		Add("InfStringConverter", &InfStringConverter{}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mappers.Frozen() {
+		t.Error("built mappers should be frozen")
+	}
+	if _, err := sesame.Get[UserMapper](mappers, UserMapperID); err != nil {
+		t.Error(err)
+	}
+	if _, err := mappers.Get("FixedTimeStringConverter"); err != nil {
+		t.Error(err)
+	}
+
+	_, err = sesame.NewBuilder().
+		Configure(func(ms sesame.Mappers) {
+			ms.Freeze()
+			_ = ms.Add("TimeStringConverter", &TimeStringConverter{})
+		}).
+		Build()
+	var se *sesame.Error
+	if !errors.As(err, &se) || !se.Frozen() {
+		t.Errorf("Build should return an error occurred in Configure: %v", err)
+	}
+}
+
+func TestMappersCycle(t *testing.T) {
+	mappers := sesame.NewMappers()
+	sesame.AddFactory(mappers, "AMapper", func(mg sesame.MapperGetter) (*TimeStringConverter, error) {
+		if _, err := mg.Get("BConverter"); err != nil {
+			return nil, err
+		}
+		return &TimeStringConverter{}, nil
+	})
+	sesame.AddFactory(mappers, "BConverter", func(mg sesame.MapperGetter) (*TimeStringConverter, error) {
+		if _, err := mg.Get("AMapper"); err != nil {
+			return nil, err
+		}
+		return &TimeStringConverter{}, nil
+	})
+
+	_, err := mappers.Get("AMapper")
+	var se *sesame.Error
+	if !errors.As(err, &se) || !se.Cycle() {
+		t.Fatalf("Get should fail with a cycle error: %v", err)
+	}
+	if !strings.Contains(err.Error(), "AMapper -> BConverter -> AMapper") {
+		t.Errorf("error should contain the chain: %v", err)
+	}
+}
+
+func TestMappersCycleAcrossGoroutines(t *testing.T) {
+	mappers := sesame.NewMappers()
+	aStarted := make(chan struct{})
+	bStarted := make(chan struct{})
+	var aOnce, bOnce sync.Once
+	sesame.AddFactory(mappers, "AMapper", func(mg sesame.MapperGetter) (*TimeStringConverter, error) {
+		aOnce.Do(func() { close(aStarted) })
+		<-bStarted
+		if _, err := mg.Get("BMapper"); err != nil {
+			return nil, err
+		}
+		return &TimeStringConverter{}, nil
+	})
+	sesame.AddFactory(mappers, "BMapper", func(mg sesame.MapperGetter) (*TimeStringConverter, error) {
+		bOnce.Do(func() { close(bStarted) })
+		<-aStarted
+		if _, err := mg.Get("AMapper"); err != nil {
+			return nil, err
+		}
+		return &TimeStringConverter{}, nil
+	})
+
+	errs := make(chan error, 2)
+	for _, id := range []string{"AMapper", "BMapper"} {
+		go func(id string) {
+			_, err := mappers.Get(id)
+			errs <- err
+		}(id)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			var se *sesame.Error
+			if !errors.As(err, &se) || !se.Cycle() {
+				t.Errorf("Get should fail with a cycle error: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("deadlock")
+		}
+	}
+}
+
+func TestMappersDependencyGraph(t *testing.T) {
+	mappers := NewMappers()
+	mapper.AddTimeToStringConverter(mappers)
+	if _, err := mappers.Get(UserMapperID); err != nil {
+		t.Fatal(err)
+	}
+	graph := mappers.DependencyGraph()
+	if diff := cmp.Diff([]string{"AddressMapper", "TimeStringConverter", "UserMapperHelper"},
+		graph[UserMapperID]); len(diff) != 0 {
+		t.Errorf("Compare value is mismatch(-:expected, +:actual) :%s\n", diff)
+	}
+}
+
+type badSignatureConverter struct{}
+
+func (c *badSignatureConverter) StringToInt(ctx context.Context, source string) int {
+	return 0
+}
+
+func (c *badSignatureConverter) Parse(ctx context.Context, source string) (int, string) {
+	return 0, ""
+}
+
+func (c *badSignatureConverter) IntToString(ctx context.Context, source *int) (string, error) {
+	return "", nil
+}
+
+func (c *badSignatureConverter) Reset() {
+}
+
+func TestAddStrict(t *testing.T) {
+	mappers := sesame.NewMappers()
+	err := sesame.AddStrict(mappers, "BadSignatureConverter", &badSignatureConverter{})
+	if err == nil {
+		t.Fatal("AddStrict should fail")
+	}
+	for _, expected := range []string{
+		"BadSignatureConverter.StringToInt is func(context.Context, string) int",
+		"BadSignatureConverter.Parse is func(context.Context, string) (int, string)",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("error should contain '%s': %v", expected, err)
+		}
+	}
+	if strings.Contains(err.Error(), "IntToString") || strings.Contains(err.Error(), "Reset") {
+		t.Errorf("error should not contain valid methods: %v", err)
+	}
+	if _, err := mappers.Get("BadSignatureConverter"); err == nil {
+		t.Error("an object should not be registered")
+	}
+	if _, err := mappers.GetFunc("", reflect.TypeOf(0), reflect.TypeOf("")); err == nil {
+		t.Error("functions should not be registered")
+	}
+
+	if err := sesame.AddStrict(mappers, "TimeStringConverter", &mapper.TimeStringConverter{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sesame.GetToPrimitiveConverterFunc[*time.Time, string](mappers, ""); err != nil {
+		t.Error(err)
+	}
+}
+
+type tagArray [5]string
+
+func TestAddConverterFunc(t *testing.T) {
+	mappers := NewGeneratedMappers()
+	if err := sesame.AddConverterFunc(mappers, "ParseTime",
+		func(ctx context.Context, source *string) (*time.Time, error) {
+			v, err := time.Parse(time.DateOnly, *source)
+			return &v, err
+		}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sesame.AddConverterFunc(mappers, "FormatTime",
+		func(ctx context.Context, source *time.Time) (string, error) {
+			return source.Format(time.DateOnly), nil
+		}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sesame.AddMapperFunc(mappers, "MapAddress",
+		func(ctx context.Context, source *domain.Address, dest *model.AddressModel) error {
+			dest.Pref = "func:" + source.Pref
+			return nil
+		}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.TODO()
+
+	userMapper, err := mappers.UserMapper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entity domain.User
+	if err := userMapper.UserModelToUser(ctx, &model.UserModel{ID: "id1", UpdatedAt: "2024-07-18"}, &entity); err != nil {
+		t.Fatal(err)
+	}
+	if !entity.UpdatedAt.Equal(time.Date(2024, 7, 18, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected result: %+v", entity)
+	}
+
+	var m model.UserModel
+	if err := userMapper.UserToUserModel(ctx, &domain.User{
+		ID:        "id1",
+		UpdatedAt: time.Date(2024, 7, 18, 0, 0, 0, 0, time.UTC),
+		Address:   &domain.Address{Pref: "Tokyo"},
+	}, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.UpdatedAt != "2024-07-18" || m.Address == nil || m.Address.Pref != "func:Tokyo" {
+		t.Errorf("unexpected result: %+v", m)
+	}
+
+	if _, err := sesame.GetToPrimitiveConverterFunc[*time.Time, string](mappers, "FormatTime"); err != nil {
+		t.Error(err)
+	}
+
+	// Named interfaces and arrays are objects like generated mappers.
+	if err := sesame.AddConverterFunc(mappers, "ParseInf",
+		func(ctx context.Context, source *string) (domain.Inf, error) {
+			return &domain.InfV{}, nil
+		}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sesame.GetToObjectConverterFunc[*string, domain.Inf](mappers, "ParseInf"); err != nil {
+		t.Error(err)
+	}
+	if err := sesame.AddConverterFunc(mappers, "ParseTags",
+		func(ctx context.Context, source *string) (tagArray, error) {
+			return tagArray{*source}, nil
+		}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sesame.GetToObjectConverterFunc[*string, tagArray](mappers, "ParseTags"); err != nil {
+		t.Error(err)
+	}
+	if err := sesame.AddConverterFunc(mappers, "ParseArray",
+		func(ctx context.Context, source *string) ([5]string, error) {
+			return [5]string{*source}, nil
+		}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sesame.GetToPrimitiveConverterFunc[*string, [5]string](mappers, "ParseArray"); err != nil {
+		t.Error(err)
+	}
+
+	err = mappers.AddFunc("Invalid", func(source string) string { return source })
+	if err == nil || !strings.Contains(err.Error(), "Invalid is func(string) string") {
+		t.Errorf("AddFunc should reject invalid functions: %v", err)
+	}
+}
+
+func TestMap(t *testing.T) {
+	mappers := NewMappers()
+	mapper.AddTimeToStringConverter(mappers)
+	ctx := context.TODO()
+	src := &model.UserModel{
+		ID:        "id1",
+		UpdatedAt: "2024-07-18T10:15:36Z",
+		Address:   &model.AddressModel{Pref: "Tokyo"},
+	}
+
+	user, err := sesame.Map[*model.UserModel, *domain.User](ctx, mappers, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != "id1" || user.Address == nil || user.Address.Pref != "Tokyo" {
+		t.Errorf("unexpected result: %+v", user)
+	}
+
+	var into domain.User
+	if err := sesame.MapInto(ctx, mappers, src, &into); err != nil {
+		t.Fatal(err)
+	}
+	if into.ID != "id1" {
+		t.Errorf("unexpected result: %+v", into)
+	}
+
+	s, err := sesame.Map[*time.Time, string](ctx, mappers, &user.UpdatedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != "2024-07-18T10:15:36Z" {
+		t.Errorf("unexpected result: %s", s)
+	}
+
+	tm, err := sesame.Map[*string, *time.Time](ctx, mappers, &s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tm.Equal(user.UpdatedAt) {
+		t.Errorf("unexpected result: %s", tm)
+	}
+
+	// Value types are mapped by functions that take pointers.
+	value, err := sesame.Map[model.UserModel, domain.User](ctx, mappers, *src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value.ID != "id1" || value.Address == nil || value.Address.Pref != "Tokyo" {
+		t.Errorf("unexpected result: %+v", value)
+	}
+	s, err = sesame.Map[time.Time, string](ctx, mappers, user.UpdatedAt)
+	if err != nil || s != "2024-07-18T10:15:36Z" {
+		t.Errorf("unexpected result: %s, %v", s, err)
+	}
+	values, err := sesame.MapSlice[model.UserModel, domain.User](ctx, mappers, []model.UserModel{*src})
+	if err != nil || len(values) != 1 || values[0].ID != "id1" {
+		t.Errorf("unexpected result: %+v, %v", values, err)
+	}
+
+	users, err := sesame.MapSlice[*model.UserModel, *domain.User](ctx, mappers,
+		[]*model.UserModel{src, {ID: "id2", UpdatedAt: "2024-07-19T10:15:36Z"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0].ID != "id1" || users[1].ID != "id2" {
+		t.Errorf("unexpected result: %+v", users)
+	}
+
+	_, err = sesame.MapSlice[*model.UserModel, *domain.User](ctx, mappers,
+		[]*model.UserModel{src, {ID: "id2", UpdatedAt: "invalid"}})
+	if err == nil || !strings.Contains(err.Error(), "at 1") {
+		t.Errorf("MapSlice should fail with an index: %v", err)
+	}
+
+	_, err = sesame.Map[*model.UserModel, *model.TodoModel](ctx, mappers, src)
+	var merr *sesame.Error
+	if !errors.As(err, &merr) || !merr.NotFound() {
+		t.Errorf("Map should fail with a not found error: %v", err)
+	}
+}
+
+func TestMappersChild(t *testing.T) {
+	parent := NewMappers()
+	mapper.AddTimeToStringConverter(parent)
+	mapper.AddIntStringConverter(parent)
+	parent.Freeze()
+	ctx := context.TODO()
+	src := &model.UserModel{ID: "id1", UpdatedAt: "2024-07-18T10:15:36Z"}
+
+	parentUser, err := sesame.Map[*model.UserModel, *domain.User](ctx, parent, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	child := parent.Child()
+	if err := child.Add("TimeStringConverter", &FixedTimeStringConverter{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sesame.AddConverterFunc(child, "ChildOnly", func(ctx context.Context, source *int) (string, error) {
+		return "child", nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	childUser, err := sesame.Map[*model.UserModel, *domain.User](ctx, child, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC); !childUser.UpdatedAt.Equal(expected) {
+		t.Errorf("child should use an overridden converter: %s", childUser.UpdatedAt)
+	}
+
+	again, err := sesame.Map[*model.UserModel, *domain.User](ctx, parent, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.UpdatedAt.Equal(parentUser.UpdatedAt) || parentUser.UpdatedAt.Year() != 2024 {
+		t.Errorf("parent should not be affected by the child: %s", again.UpdatedAt)
+	}
+	if _, err := parent.Get("ChildOnly"); err == nil {
+		t.Error("parent should not have objects added to the child")
+	}
+
+	obj1, err := child.Get(UserMapperID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	childUserMapper := obj1
+	obj2, err := parent.Get(UserMapperID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj1 == obj2 {
+		t.Error("objects created by factories should be created again in the child")
+	}
+	obj1, _ = child.Get("IntStringConverter")
+	obj2, _ = parent.Get("IntStringConverter")
+	if obj1 == nil || obj1 != obj2 {
+		t.Error("objects added to the parent should be shared with the child")
+	}
+
+	// Objects created by factories of the parent depend on objects in the child.
+	if err := child.Replace("TimeStringConverter", &TimeStringConverter{}); err != nil {
+		t.Fatal(err)
+	}
+	replaced, err := child.Get(UserMapperID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replaced == childUserMapper {
+		t.Error("objects created by factories of the parent should be created again after Replace")
+	}
+	childUser, err = sesame.Map[*model.UserModel, *domain.User](ctx, child, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if childUser.UpdatedAt.Year() != 2024 {
+		t.Errorf("child should use a replaced converter: %s", childUser.UpdatedAt)
+	}
+}
+
+func TestMappersChildParentReplace(t *testing.T) {
+	parent := NewMappers()
+	mapper.AddTimeToStringConverter(parent)
+	child := parent.Child()
+	grandchild := child.Child()
+	ctx := context.TODO()
+	src := &model.UserModel{ID: "id1", UpdatedAt: "2024-07-18T10:15:36Z"}
+
+	for _, ms := range []sesame.Mappers{child, grandchild} {
+		user, err := sesame.Map[*model.UserModel, *domain.User](ctx, ms, src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.UpdatedAt.Year() != 2024 {
+			t.Errorf("unexpected result: %s", user.UpdatedAt)
+		}
+	}
+	old, err := child.Get(UserMapperID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := parent.Replace("TimeStringConverter", &FixedTimeStringConverter{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, ms := range []sesame.Mappers{child, grandchild} {
+		user, err := sesame.Map[*model.UserModel, *domain.User](ctx, ms, src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.UpdatedAt.Year() != 2021 {
+			t.Errorf("children should use a converter replaced in the parent: %s", user.UpdatedAt)
+		}
+	}
+	if obj, _ := child.Get(UserMapperID); obj == old {
+		t.Error("objects created in the child should be created again after the parent is modified")
+	}
+}
+
+func TestMappersRemoveAndReplace(t *testing.T) {
+	mappers := NewMappers()
+	mapper.AddTimeToStringConverter(mappers)
+	ctx := context.TODO()
+	src := &model.UserModel{ID: "id1", UpdatedAt: "2024-07-18T10:15:36Z"}
+
+	user, err := sesame.Map[*model.UserModel, *domain.User](ctx, mappers, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.UpdatedAt.Year() != 2024 {
+		t.Errorf("unexpected result: %s", user.UpdatedAt)
+	}
+
+	if err := mappers.Replace("TimeStringConverter", &FixedTimeStringConverter{}); err != nil {
+		t.Fatal(err)
+	}
+	user, err = sesame.Map[*model.UserModel, *domain.User](ctx, mappers, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.UpdatedAt.Year() != 2021 {
+		t.Errorf("mappers should use a replaced converter: %s", user.UpdatedAt)
+	}
+
+	if err := mappers.Remove("TimeStringConverter"); err != nil {
+		t.Fatal(err)
+	}
+	var merr *sesame.Error
+	_, err = sesame.GetToObjectConverterFunc[*string, *time.Time](mappers, "")
+	if !errors.As(err, &merr) || !merr.NotFound() {
+		t.Errorf("functions of a removed object should be removed: %v", err)
+	}
+	user, err = sesame.Map[*model.UserModel, *domain.User](ctx, mappers, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.UpdatedAt.IsZero() {
+		t.Errorf("mappers should not use a removed converter: %s", user.UpdatedAt)
+	}
+
+	err = mappers.Remove("TimeStringConverter")
+	if !errors.As(err, &merr) || !merr.NotFound() {
+		t.Errorf("Remove should fail with a not found error: %v", err)
+	}
+
+	// Adding an object with a different method set again must drop old functions.
+	if err := mappers.Add("TimeStringConverter", &TimeStringConverter{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mappers.Add("TimeStringConverter", &InfStringConverter{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"", "TimeStringConverter"} {
+		_, err = sesame.GetToObjectConverterFunc[*string, *time.Time](mappers, id)
+		if !errors.Is(err, sesame.ErrNotFound) {
+			t.Errorf("functions of an old object should be removed: %v", err)
+		}
+	}
+	if _, err := sesame.GetToObjectConverterFunc[*string, domain.Inf](mappers, "TimeStringConverter"); err != nil {
+		t.Errorf("functions of a new object should be added: %v", err)
+	}
+
+	// A failed Replace must keep the old object and its functions.
+	if err := mappers.Replace("TimeStringConverter", &badSignatureConverter{}, sesame.WithStrict()); err == nil {
+		t.Error("Replace should fail")
+	}
+	if _, err := sesame.GetToObjectConverterFunc[*string, domain.Inf](mappers, "TimeStringConverter"); err != nil {
+		t.Errorf("an old object should remain after a failed Replace: %v", err)
+	}
+
+	mappers.Freeze()
+	err = mappers.Replace(UserMapperID, &FixedTimeStringConverter{})
+	if !errors.As(err, &merr) || !merr.Frozen() {
+		t.Errorf("Replace should fail with a frozen error: %v", err)
+	}
+}
+
+func TestMappersMergeWith(t *testing.T) {
+	newMappers := func() (sesame.Mappers, sesame.Mappers) {
+		existing := sesame.NewMappers()
+		existing.Add("TimeStringConverter", &TimeStringConverter{})
+		merged := sesame.NewMappers()
+		merged.Add("TimeStringConverter", &FixedTimeStringConverter{})
+		merged.Add("ClockConverter", &FixedTimeStringConverter{})
+		merged.Add("IntStringConverter", &IntStringConverter{})
+		return existing, merged
+	}
+	parseYear := func(ms sesame.Mappers) int {
+		f, err := sesame.GetToObjectConverterFunc[*string, *time.Time](ms, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := "2024-07-18T10:15:36Z"
+		v, err := f(context.TODO(), &s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v.Year()
+	}
+
+	existing, merged := newMappers()
+	report, err := existing.MergeWith(merged, sesame.OnConflict(sesame.ConflictError))
+	if err == nil {
+		t.Fatal("MergeWith should fail")
+	}
+	if diff := cmp.Diff([]string{"TimeStringConverter"}, report.ObjectIDs); len(diff) != 0 {
+		t.Errorf("Compare value is mismatch(-:expected, +:actual) :%s\n", diff)
+	}
+	if diff := cmp.Diff([]sesame.FunctionConflict{
+		{
+			SourceType:  "string",
+			DestType:    "time#Time",
+			ExistingIDs: []string{"TimeStringConverter"},
+			MergedIDs:   []string{"ClockConverter", "TimeStringConverter"},
+		},
+		{
+			SourceType:  "time#Time",
+			DestType:    "string",
+			ExistingIDs: []string{"TimeStringConverter"},
+			MergedIDs:   []string{"ClockConverter", "TimeStringConverter"},
+		},
+	}, report.Functions); len(diff) != 0 {
+		t.Errorf("Compare value is mismatch(-:expected, +:actual) :%s\n", diff)
+	}
+	if _, err := existing.Get("IntStringConverter"); err == nil {
+		t.Error("MergeWith should merge nothing on conflicts")
+	}
+
+	existing, merged = newMappers()
+	if _, err := existing.MergeWith(merged, sesame.OnConflict(sesame.KeepExisting)); err != nil {
+		t.Fatal(err)
+	}
+	if obj, _ := existing.Get("TimeStringConverter"); reflect.TypeOf(obj) != reflect.TypeOf(&TimeStringConverter{}) {
+		t.Errorf("an existing object should be kept: %T", obj)
+	}
+	if year := parseYear(existing); year != 2024 {
+		t.Errorf("an existing function should be used: %d", year)
+	}
+	if _, err := existing.Get("IntStringConverter"); err != nil {
+		t.Error(err)
+	}
+
+	existing, merged = newMappers()
+	report, err = existing.MergeWith(merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.HasConflicts() {
+		t.Error("report should have conflicts")
+	}
+	if year := parseYear(existing); year != 2021 {
+		t.Errorf("a merged function should be used: %d", year)
+	}
+
+	parent := sesame.NewMappers()
+	mapper.AddTimeToStringConverter(parent)
+	if _, err := parent.Get("FixedTimeStringConverter"); err != nil {
+		t.Fatal(err)
+	}
+	child := parent.Child()
+	child.Add("TimeStringConverter", &FixedTimeStringConverter{})
+	child.Add("IntStringConverter", &IntStringConverter{})
+	existing = sesame.NewMappers()
+	if _, err := existing.MergeWith(child); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"TimeStringConverter", "FixedTimeStringConverter", "IntStringConverter"} {
+		if _, err := existing.Get(id); err != nil {
+			t.Errorf("objects of a child and its parents should be merged: %v", err)
+		}
+	}
+	if obj, _ := existing.Get("TimeStringConverter"); reflect.TypeOf(obj) != reflect.TypeOf(&FixedTimeStringConverter{}) {
+		t.Errorf("an object overridden by a child should be merged: %T", obj)
+	}
+	if year := parseYear(existing); year != 2021 {
+		t.Errorf("a function of a child should be used: %d", year)
+	}
+}
+
+func TestMappersDescribe(t *testing.T) {
+	mappers := NewMappers()
+	mapper.AddTimeToStringConverter(mappers)
+	mappers.Add("ClockConverter", &FixedTimeStringConverter{})
+	if _, err := mappers.Get(UserMapperID); err != nil {
+		t.Fatal(err)
+	}
+
+	desc := mappers.Describe()
+	objects := map[string]sesame.ObjectDescription{}
+	for _, o := range desc.Objects {
+		objects[o.ID] = o
+	}
+	if diff := cmp.Diff(sesame.ObjectDescription{
+		ID:      UserMapperID,
+		Kind:    sesame.KindMapper,
+		Type:    "*mapper.usermapper",
+		Factory: true,
+		Global:  true,
+	}, objects[UserMapperID]); len(diff) != 0 {
+		t.Errorf("Compare value is mismatch(-:expected, +:actual) :%s\n", diff)
+	}
+	if diff := cmp.Diff(sesame.ObjectDescription{
+		ID:      "FixedTimeStringConverter",
+		Kind:    sesame.KindConverter,
+		Factory: true,
+	}, objects["FixedTimeStringConverter"]); len(diff) != 0 {
+		t.Errorf("Compare value is mismatch(-:expected, +:actual) :%s\n", diff)
+	}
+
+	if diff := cmp.Diff([]sesame.FunctionDescription{
+		{SourceType: "time#Time", DestType: "string", ObjectID: "ClockConverter",
+			Method: "TimeToString", Global: true, Active: true},
+		{SourceType: "time#Time", DestType: "string", ObjectID: "FixedTimeStringConverter",
+			Method: "TimeToString"},
+		{SourceType: "time#Time", DestType: "string", ObjectID: "TimeStringConverter",
+			Method: "TimeToString", Global: true},
+	}, sortedFunctions(desc.FunctionsFor("time#Time", "string"))); len(diff) != 0 {
+		t.Errorf("Compare value is mismatch(-:expected, +:actual) :%s\n", diff)
+	}
+
+	rec := httptest.NewRecorder()
+	sesamedebug.Handler(mappers).ServeHTTP(rec,
+		httptest.NewRequest(http.MethodGet, "/?source=time%23Time&dest=string", nil))
+	var served sesame.Description
+	if err := json.Unmarshal(rec.Body.Bytes(), &served); err != nil {
+		t.Fatal(err)
+	}
+	if len(served.Functions) != 3 || len(served.Objects) != len(desc.Objects) {
+		t.Errorf("unexpected result: %s", rec.Body.String())
+	}
+}
+
+func sortedFunctions(fs []sesame.FunctionDescription) []sesame.FunctionDescription {
+	sort.Slice(fs, func(i, j int) bool { return fs[i].ObjectID < fs[j].ObjectID })
+	return fs
+}
+
+func TestMappersInterceptor(t *testing.T) {
+	var calls []string
+	mappers := NewMappers(
+		sesame.WithInterceptor(func(ctx context.Context, info sesame.CallInfo, next func() error) error {
+			calls = append(calls, fmt.Sprintf("%s.%s(%s -> %s)", info.ObjectID, info.Method, info.SourceType, info.DestType))
+			return next()
+		}),
+		sesame.WithInterceptor(func(ctx context.Context, info sesame.CallInfo, next func() error) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("recovered: %v", r)
+				}
+			}()
+			return next()
+		}))
+	mapper.AddTimeToStringConverter(mappers)
+	ctx := context.TODO()
+
+	if _, err := sesame.Map[*model.UserModel, *domain.User](ctx, mappers, &model.UserModel{
+		ID:        "id1",
+		UpdatedAt: "2024-07-18T10:15:36Z",
+		Address:   &model.AddressModel{Pref: "Tokyo"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{
+		"UserMapper.UserModelToUser(example.com/testmod/model#UserModel -> example.com/testmod/domain#User)",
+		"TimeStringConverter.StringToTime(string -> time#Time)",
+		"AddressMapper.AddressModelToAddress(example.com/testmod/model#AddressModel -> example.com/testmod/domain#Address)",
+	}, calls); len(diff) != 0 {
+		t.Errorf("Compare value is mismatch(-:expected, +:actual) :%s\n", diff)
+	}
+
+	if err := sesame.AddConverterFunc(mappers, "PanicConverter", func(ctx context.Context, source *int) (string, error) {
+		panic("boom")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	v := 1
+	if _, err := sesame.Map[*int, string](ctx, mappers, &v); err == nil || err.Error() != "recovered: boom" {
+		t.Errorf("an interceptor should recover a panic: %v", err)
+	}
+}
+
+func TestMappersLogger(t *testing.T) {
+	var buf bytes.Buffer
+	mappers := NewMappers(sesame.WithLogger(slog.New(slog.NewTextHandler(&buf,
+		&slog.HandlerOptions{Level: slog.LevelDebug}))))
+	mapper.AddTimeToStringConverter(mappers)
+	ctx := context.TODO()
+
+	if _, err := sesame.Map[*model.UserModel, *domain.User](ctx, mappers, &model.UserModel{
+		ID:        "id1",
+		UpdatedAt: "2024-07-18T10:15:36Z",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mappers.Get("NotFound"); err == nil {
+		t.Fatal("NotFound should not be found")
+	}
+	logs := buf.String()
+	for _, expected := range []string{
+		`msg="Create an object" id=UserMapper`,
+		`msg="Resolve a function"`,
+		`msg="Object not found" id=NotFound`,
+	} {
+		if !strings.Contains(logs, expected) {
+			t.Errorf("logs should contain %q:\n%s", expected, logs)
+		}
+	}
+
+	child := mappers.Child()
+	buf.Reset()
+	if _, err := child.Get("NotFound"); err == nil {
+		t.Fatal("NotFound should not be found")
+	}
+	if !strings.Contains(buf.String(), "Object not found") {
+		t.Errorf("a child should inherit a logger:\n%s", buf.String())
+	}
+}
+
+func TestInstrumentedMapper(t *testing.T) {
+	var calls []string
+	mappers := NewMappers()
+	mapper.AddTimeToStringConverter(mappers)
+	sesame.AddObserver(mappers, sesame.ObserverFunc(func(ctx context.Context, mapper, method string) func(error) {
+		calls = append(calls, "start "+mapper+"."+method)
+		return func(err error) {
+			calls = append(calls, fmt.Sprintf("end %s.%s: %v", mapper, method, err))
+		}
+	}))
+	if err := sesame.AddConverterFunc(mappers, "FailingDate1Converter",
+		func(ctx context.Context, source *model.Date1) (*domain.Date1, error) {
+			if source == nil {
+				return nil, nil
+			}
+			return nil, errors.New("invalid date")
+		}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.TODO()
+
+	if _, err := sesame.Map[*model.UserModel, *domain.User](ctx, mappers, &model.UserModel{
+		ID:        "id1",
+		UpdatedAt: "2024-07-18T10:15:36Z",
+		Address:   &model.AddressModel{Pref: "Tokyo"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := sesame.Map[*model.AddressModel, *domain.Address](ctx, mappers, &model.AddressModel{
+		Date: &model.Date1{Year: "2024"},
+	})
+	if err == nil {
+		t.Fatal("Map should fail")
+	}
+	if diff := cmp.Diff([]string{
+		"start AddressMapper.AddressModelToAddress",
+		"end AddressMapper.AddressModelToAddress: <nil>",
+		"start AddressMapper.AddressModelToAddress",
+		"end AddressMapper.AddressModelToAddress: " + err.Error(),
+	}, calls); len(diff) != 0 {
+		t.Errorf("Compare value is mismatch(-:expected, +:actual) :%s\n", diff)
+	}
+}
+
+type closableConverter struct {
+	TimeStringConverter
+	name   string
+	closed *[]string
+}
+
+func (c *closableConverter) Close() error {
+	*c.closed = append(*c.closed, c.name)
+	if c.name == "broken" {
+		return errors.New("broken")
+	}
+	return nil
+}
+
+type tenantKey struct{}
+
+func TestMappersLifecycle(t *testing.T) {
+	mappers := sesame.NewMappers()
+	var closed []string
+	var created atomic.Int32
+	newConverter := func(name string) func(sesame.MapperGetter) (*closableConverter, error) {
+		return func(mg sesame.MapperGetter) (*closableConverter, error) {
+			created.Add(1)
+			return &closableConverter{name: name, closed: &closed}, nil
+		}
+	}
+	sesame.AddFactory(mappers, "TransientConverter", newConverter("transient"),
+		sesame.WithLifecycle(sesame.Transient), sesame.WithNoGlobals())
+	obj1, _ := mappers.Get("TransientConverter")
+	obj2, _ := mappers.Get("TransientConverter")
+	if obj1 == obj2 || created.Load() != 2 {
+		t.Error("transient objects should be created on every Get")
+	}
+
+	err := sesame.AddFactory(mappers, "TenantConverter", newConverter("tenant"),
+		sesame.WithLifecycle(sesame.PerContext))
+	if err == nil {
+		t.Error("per-context objects without a context key should be an error")
+	}
+	sesame.AddFactory(mappers, "TenantConverter", func(mg sesame.MapperGetter) (*closableConverter, error) {
+		tenant, _ := mg.Get("TransientConverter")
+		return &closableConverter{name: "tenant:" + tenant.(*closableConverter).name, closed: &closed}, nil
+	}, sesame.WithLifecycle(sesame.PerContext), sesame.WithContextKey(tenantKey{}))
+
+	ctxA := context.WithValue(context.TODO(), tenantKey{}, "A")
+	ctxB := context.WithValue(context.TODO(), tenantKey{}, "B")
+	a1, err := mappers.ForContext(ctxA).Get("TenantConverter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a2, _ := mappers.ForContext(ctxA).Get("TenantConverter")
+	b, _ := mappers.ForContext(ctxB).Get("TenantConverter")
+	if a1 != a2 || a1 == b {
+		t.Error("per-context objects should be created once per context value")
+	}
+	if _, err := mappers.Get("TenantConverter"); err == nil {
+		t.Error("per-context objects without a context should be an error")
+	}
+	if _, err := mappers.ForContext(context.TODO()).Get("TenantConverter"); err == nil {
+		t.Error("per-context objects without a context value should be an error")
+	}
+	if _, err := sesame.Map[*time.Time, string](ctxA, mappers, &time.Time{}); err != nil {
+		t.Errorf("Map should resolve per-context functions: %v", err)
+	}
+
+	sesame.AddFactory(mappers, "CapturingConverter", func(mg sesame.MapperGetter) (*closableConverter, error) {
+		if _, err := mg.Get("TenantConverter"); err != nil {
+			return nil, err
+		}
+		return &closableConverter{name: "capturing", closed: &closed}, nil
+	}, sesame.WithNoGlobals())
+	if _, err := mappers.ForContext(ctxA).Get("CapturingConverter"); err == nil {
+		t.Error("singletons should not depend on per-context objects")
+	}
+
+	sesame.AddFactory(mappers, "BrokenConverter", newConverter("broken"), sesame.WithNoGlobals())
+	sesame.AddFactory(mappers, "SingletonConverter", newConverter("singleton"), sesame.WithNoGlobals())
+	broken, _ := mappers.Get("BrokenConverter")
+	singleton, _ := mappers.Get("SingletonConverter")
+	if obj, _ := mappers.Get("SingletonConverter"); obj != singleton {
+		t.Error("singleton objects should be created once")
+	}
+
+	err = mappers.Close()
+	if err == nil || err.Error() != "failed to close BrokenConverter: broken" {
+		t.Errorf("Close should return errors of Close methods: %v", err)
+	}
+	if diff := cmp.Diff([]string{"singleton", "broken", "tenant:transient", "tenant:transient"}, closed); len(diff) != 0 {
+		t.Errorf("objects should be closed in reverse creation order(-:expected, +:actual) :%s\n", diff)
+	}
+	if obj, _ := mappers.Get("BrokenConverter"); obj == broken {
+		t.Error("objects should be created again after Close")
+	}
+	if obj, _ := mappers.ForContext(ctxA).Get("TenantConverter"); obj == a1 {
+		t.Error("per-context objects should be created again after Close")
+	}
+}
+
+func TestValidateClosesPerContextObjects(t *testing.T) {
+	mappers := sesame.NewMappers()
+	var closed []string
+	sesame.AddFactory(mappers, "TenantMapper", func(mg sesame.MapperGetter) (*closableConverter, error) {
+		return &closableConverter{name: "tenant", closed: &closed}, nil
+	}, sesame.WithLifecycle(sesame.PerContext), sesame.WithContextKey(tenantKey{}), sesame.WithNoGlobals())
+	if err := mappers.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"tenant"}, closed); len(diff) != 0 {
+		t.Errorf("per-context objects created by Validate should be closed(-:expected, +:actual) :%s\n", diff)
+	}
+	if err := mappers.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(closed) != 1 {
+		t.Errorf("per-context objects created by Validate should not be retained: %v", closed)
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	mappers := NewMappers()
+	mapper.AddTimeToStringConverter(mappers)
+	var merr *sesame.Error
+
+	_, err := mappers.Get("UnknownConverter")
+	if !errors.Is(err, sesame.ErrNotFound) || !errors.As(err, &merr) || merr.ID != "UnknownConverter" {
+		t.Errorf("Get should return ErrNotFound with an id: %v", err)
+	}
+	_, err = mappers.GetFuncByTypeName("", "int", "time#Time")
+	if !errors.Is(err, sesame.ErrNotFound) || !errors.As(err, &merr) ||
+		merr.SourceType != "int" || merr.DestType != "time#Time" {
+		t.Errorf("GetFunc should return ErrNotFound with type names: %v", err)
+	}
+	if err := mappers.Validate(); !errors.Is(err, sesame.ErrNotFound) {
+		t.Errorf("Validate should return ErrNotFound: %v", err)
+	}
+
+	_, err = sesame.Get[*InfStringConverter](mappers, "TimeStringConverter")
+	if !errors.Is(err, sesame.ErrTypeMismatch) || !errors.As(err, &merr) || merr.ID != "TimeStringConverter" {
+		t.Errorf("Get should return ErrTypeMismatch: %v", err)
+	}
+	_, err = sesame.GetMapperFunc[*time.Time, *string](mappers, "TimeStringConverter")
+	if !errors.Is(err, sesame.ErrTypeMismatch) || !errors.As(err, &merr) || merr.SourceType != "time#Time" {
+		t.Errorf("GetMapperFunc should return ErrTypeMismatch: %v", err)
+	}
+
+	if err := mappers.AddFunc("NotFunc", 1); !errors.Is(err, sesame.ErrInvalidSignature) {
+		t.Errorf("AddFunc should return ErrInvalidSignature: %v", err)
+	}
+
+	cause := errors.New("connection refused")
+	sesame.AddFactory(mappers, "BrokenConverter", func(mg sesame.MapperGetter) (*TimeStringConverter, error) {
+		return nil, cause
+	}, sesame.WithNoGlobals())
+	_, err = mappers.Get("BrokenConverter")
+	if !errors.Is(err, sesame.ErrFactoryFailed) || !errors.Is(err, cause) ||
+		!errors.As(err, &merr) || merr.ID != "BrokenConverter" {
+		t.Errorf("Get should return ErrFactoryFailed with a cause: %v", err)
+	}
+
+	sesame.AddFactory(mappers, "SelfConverter", func(mg sesame.MapperGetter) (*TimeStringConverter, error) {
+		_, err := mg.Get("SelfConverter")
+		return nil, err
+	}, sesame.WithNoGlobals())
+	if _, err = mappers.Get("SelfConverter"); !errors.Is(err, sesame.ErrCycle) || errors.Is(err, sesame.ErrFactoryFailed) {
+		t.Errorf("Get should return ErrCycle: %v", err)
+	}
+
+	err = sesame.AddFactory(mappers, "TenantConverter", func(mg sesame.MapperGetter) (*TimeStringConverter, error) {
+		return &TimeStringConverter{}, nil
+	}, sesame.WithLifecycle(sesame.PerContext), sesame.WithNoGlobals())
+	if !errors.Is(err, sesame.ErrContextKey) || !errors.As(err, &merr) || merr.ID != "TenantConverter" {
+		t.Errorf("AddFactory should return ErrContextKey: %v", err)
+	}
+	sesame.AddFactory(mappers, "TenantConverter", func(mg sesame.MapperGetter) (*TimeStringConverter, error) {
+		return &TimeStringConverter{}, nil
+	}, sesame.WithLifecycle(sesame.PerContext), sesame.WithContextKey(tenantKey{}), sesame.WithNoGlobals())
+	for _, ctx := range []context.Context{
+		nil,
+		context.TODO(),
+		context.WithValue(context.TODO(), tenantKey{}, []string{"A"}),
+	} {
+		if _, err := mappers.ForContext(ctx).Get("TenantConverter"); !errors.Is(err, sesame.ErrContextKey) {
+			t.Errorf("Get should return ErrContextKey: %v", err)
+		}
+	}
+
+	merged := sesame.NewMappers()
+	merged.Add("TimeStringConverter", &TimeStringConverter{})
+	if _, err := mappers.MergeWith(merged, sesame.OnConflict(sesame.ConflictError)); !errors.Is(err, sesame.ErrConflict) {
+		t.Errorf("MergeWith should return ErrConflict: %v", err)
+	}
+	if _, err := mappers.MergeWith(struct{ sesame.MapperGetter }{merged}); !errors.Is(err, sesame.ErrTypeMismatch) {
+		t.Errorf("MergeWith should return ErrTypeMismatch: %v", err)
+	}
+
+	mappers.Freeze()
+	err = mappers.Add("InfStringConverter", &InfStringConverter{})
+	if !errors.Is(err, sesame.ErrFrozen) || !errors.As(err, &merr) || merr.ID != "InfStringConverter" {
+		t.Errorf("Add should return ErrFrozen: %v", err)
+	}
+	if errors.Is(err, sesame.ErrNotFound) {
+		t.Error("ErrFrozen should not be ErrNotFound")
+	}
+}
@@ -186,3 +186,16 @@ func (m *PrioritiesStringConverter) SliceToString(ctx context.Context, source []
 func AddPrioritiesConverter(mappers sesame.Mappers) {
 	mappers.Add("PrioritiesStringConverter", &PrioritiesStringConverter{})
 }
+
+type UpperCaseNameHelper struct {
+}
+
+func (h *UpperCaseNameHelper) UserModelToUser(ctx context.Context, source *model.UserModel, dest *domain.User) error {
+	dest.Name = strings.ToUpper(source.Name)
+	return nil
+}
+
+func (h *UpperCaseNameHelper) UserToUserModel(ctx context.Context, source *domain.User, dest *model.UserModel) error {
+	dest.Name = strings.ToLower(source.Name)
+	return nil
+}
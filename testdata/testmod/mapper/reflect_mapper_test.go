@@ -0,0 +1,252 @@
+package mapper_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yuin/sesame"
+
+	"example.com/testmod/domain"
+	"example.com/testmod/mapper"
+	. "example.com/testmod/mapper"
+	"example.com/testmod/model"
+)
+
+// Reflect mappings below must be the same as mappings in sesame.yml .
+var todoReflectMapping = sesame.ReflectMapping{
+	IgnoreCase: true,
+	NilMap:     sesame.NilCollectionAsNil,
+	NilSlice:   sesame.NilCollectionAsNil,
+	Fields: []sesame.ReflectField{
+		{A: "Done", B: "Finished"},
+		{A: "UserID", B: "User.ID"},
+		{A: "UserAddress", B: "User.Address"},
+		{A: "CreatedAt", B: "CreatedAt", Uses: "FixedTimeStringConverter"},
+		{A: "Priorities", B: "Priorities", Uses: "PrioritiesStringConverter"},
+		{A: "Priorities", B: "PrioritiesPtr", Uses: "PrioritiesStringConverter"},
+	},
+	Ignores: []sesame.ReflectField{
+		{A: "ValidateOnly"},
+		{B: "User"},
+	},
+	Helper: "testdata.TodoMapperHelper",
+}
+
+var todoEmptyReflectMapping = sesame.ReflectMapping{
+	NilMap:   sesame.NilCollectionAsEmpty,
+	NilSlice: sesame.NilCollectionAsEmpty,
+	Fields: []sesame.ReflectField{
+		{A: "CreatedAt", B: "CreatedAt", Uses: "FixedTimeStringConverter"},
+		{A: "Priorities", B: "Priorities", Uses: "PrioritiesStringConverter"},
+		{A: "Priorities", B: "PrioritiesPtr", Uses: "PrioritiesStringConverter"},
+	},
+	Ignores: []sesame.ReflectField{
+		{A: "Id"}, {A: "UserID"}, {A: "UserAddress"}, {A: "Done"}, {A: "ValidateOnly"},
+		{B: "ID"}, {B: "User"}, {B: "Finished"},
+	},
+	Helper: "TodoEmptyMapperHelper",
+}
+
+var addressReflectMapping = sesame.ReflectMapping{
+	IgnoreCase: true,
+	Fields: []sesame.ReflectField{
+		{A: "Street", B: "Street", Uses: "StreetConverter"},
+		{A: "IntValues", B: "StringValues", UsesForElements: "IntStringConverter"},
+	},
+	Helper: "AddressMapperHelper",
+}
+
+var userReflectMapping = sesame.ReflectMapping{
+	IgnoreCase: true,
+	Helper:     "UserMapperHelper",
+}
+
+func addReflectMappers(mappers sesame.Mappers) {
+	sesame.AddFactory(mappers, TodoMapperID,
+		func(mg sesame.MapperGetter) (*sesame.ReflectMapper[model.TodoModel, domain.Todo], error) {
+			return sesame.NewReflectMapper[model.TodoModel, domain.Todo](mg, todoReflectMapping)
+		})
+	sesame.AddFactory(mappers, TodoEmptyMapperID,
+		func(mg sesame.MapperGetter) (*sesame.ReflectMapper[model.TodoModel, domain.Todo], error) {
+			return sesame.NewReflectMapper[model.TodoModel, domain.Todo](mg, todoEmptyReflectMapping)
+		})
+	sesame.AddFactory(mappers, AddressMapperID,
+		func(mg sesame.MapperGetter) (*sesame.ReflectMapper[model.AddressModel, domain.Address], error) {
+			return sesame.NewReflectMapper[model.AddressModel, domain.Address](mg, addressReflectMapping)
+		})
+	sesame.AddFactory(mappers, UserMapperID,
+		func(mg sesame.MapperGetter) (*sesame.ReflectMapper[model.UserModel, domain.User], error) {
+			return sesame.NewReflectMapper[model.UserModel, domain.User](mg, userReflectMapping)
+		})
+}
+
+func addParityConverters(mappers sesame.Mappers) {
+	mapper.AddTimeToStringConverter(mappers)
+	mapper.AddInfToStringConverter(mappers)
+	mapper.AddStreetConverter(mappers)
+	mapper.AddIntStringConverter(mappers)
+	mapper.AddDate1Converter(mappers)
+	mapper.AddPrioritiesConverter(mappers)
+	mappers.Add("testdata.TodoMapperHelper", &todoMapperHelper{})
+}
+
+// assertParity maps source by a generated mapper and a ReflectMapper
+// registered with the same id, and compares results.
+func assertParity[S any, D any](t *testing.T, generated, reflected sesame.Mappers, id string,
+	source *S, opts ...cmp.Option) {
+	t.Helper()
+	ctx := context.TODO()
+	var results [2]*D
+	for i, mappers := range []sesame.Mappers{generated, reflected} {
+		obj, err := mappers.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := findMapFunc[S, D](obj)
+		if f == nil {
+			t.Fatalf("%T does not have a function for %T -> %T", obj, source, results[i])
+		}
+		results[i] = new(D)
+		if err := f(ctx, source, results[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if diff := cmp.Diff(results[0], results[1], opts...); len(diff) != 0 {
+		t.Errorf("%s: results are mismatch(-:generated, +:reflect) :%s\n", id, diff)
+	}
+	if p, ok := any(results[0]).(interface{ PrivateValue() int }); ok {
+		if p.PrivateValue() != any(results[1]).(interface{ PrivateValue() int }).PrivateValue() {
+			t.Errorf("%s: private fields with getter/setter must be mapped", id)
+		}
+	}
+}
+
+func findMapFunc[S any, D any](obj any) func(context.Context, *S, *D) error {
+	switch m := obj.(type) {
+	case interface {
+		AToB(context.Context, *S, *D) error
+	}:
+		return m.AToB
+	case interface {
+		BToA(context.Context, *S, *D) error
+	}:
+		return m.BToA
+	case TodoMapper:
+		if f, ok := any(m.TodoModelToTodo).(func(context.Context, *S, *D) error); ok {
+			return f
+		}
+		f, _ := any(m.TodoToTodoModel).(func(context.Context, *S, *D) error)
+		return f
+	case AddressMapper:
+		if f, ok := any(m.AddressModelToAddress).(func(context.Context, *S, *D) error); ok {
+			return f
+		}
+		f, _ := any(m.AddressToAddressModel).(func(context.Context, *S, *D) error)
+		return f
+	case UserMapper:
+		if f, ok := any(m.UserModelToUser).(func(context.Context, *S, *D) error); ok {
+			return f
+		}
+		f, _ := any(m.UserToUserModel).(func(context.Context, *S, *D) error)
+		return f
+	}
+	return nil
+}
+
+func TestReflectMapperParity(t *testing.T) {
+	generated := NewMappers()
+	addParityConverters(generated)
+	reflected := sesame.NewMappers()
+	addParityConverters(reflected)
+	addReflectMappers(reflected)
+
+	address := &model.AddressModel{
+		Pref:      "Tokyo",
+		Street:    []int{1, 2, 3},
+		IntValues: []int{4, 5},
+		Date:      &model.Date1{Year: "2021"},
+	}
+	todos := []*model.TodoModel{
+		{UpdatedAt: "2023-07-18T10:15:36Z"},
+		{
+			Id:          1,
+			UserID:      "AAA",
+			UserAddress: address,
+			Title:       "Write unit tests",
+			Type:        1,
+			Attributes: map[string][]string{
+				"Date":     {"20240101", "20240130"},
+				"Priority": nil,
+			},
+			Tags:         [5]string{"Task"},
+			Priorities:   "High,Medium",
+			Done:         true,
+			UpdatedAt:    "2023-07-18T10:15:36Z",
+			CreatedAt:    "2021-01-01T00:00:00Z",
+			Inf:          "hoge",
+			ValidateOnly: true,
+		},
+	}
+	todos[1].SetPrivateValue(10)
+
+	for _, id := range []string{TodoMapperID, TodoEmptyMapperID} {
+		for _, todo := range todos {
+			assertParity[model.TodoModel, domain.Todo](t, generated, reflected, id, todo, todoEntityIgnores)
+
+			var entity domain.Todo
+			if err := sesame.MapInto(context.TODO(), generated, todo, &entity); err != nil {
+				t.Fatal(err)
+			}
+			entity.SetPrivateValue(todo.PrivateValue())
+			assertParity[domain.Todo, model.TodoModel](t, generated, reflected, id, &entity, todoModelIgnores)
+		}
+	}
+
+	for _, source := range []*model.AddressModel{{Date: &model.Date1{Year: "1"}}, address} {
+		assertParity[model.AddressModel, domain.Address](t, generated, reflected, AddressMapperID, source)
+	}
+	for _, source := range []*domain.Address{{}, {Pref: "Tokyo", Street: "1-2", StringValues: []string{"1", "2"}}} {
+		assertParity[domain.Address, model.AddressModel](t, generated, reflected, AddressMapperID, source)
+	}
+
+	for _, source := range []*model.UserModel{{UpdatedAt: "2024-07-18T10:15:36Z"}, {ID: "id1", Name: "alice", UpdatedAt: "2024-07-18T10:15:36Z", Address: address}} {
+		assertParity[model.UserModel, domain.User](t, generated, reflected, UserMapperID, source)
+	}
+	for _, source := range []*domain.User{{}, {ID: "id1", Name: "alice", UpdatedAt: mustTime("2024-07-18T10:15:36Z"),
+		Address: &domain.Address{Pref: "Tokyo"}}} {
+		assertParity[domain.User, model.UserModel](t, generated, reflected, UserMapperID, source)
+	}
+}
+
+func TestReflectMapper(t *testing.T) {
+	mappers := sesame.NewMappers()
+	addParityConverters(mappers)
+	addReflectMappers(mappers)
+
+	user, err := sesame.Map[*model.UserModel, *domain.User](context.TODO(), mappers, &model.UserModel{
+		ID:        "id1",
+		UpdatedAt: "2024-07-18T10:15:36Z",
+		Address:   &model.AddressModel{Pref: "Tokyo", Street: []int{1, 2}, Date: &model.Date1{Year: "2021"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&domain.User{
+		ID:        "id1",
+		UpdatedAt: mustTime("2024-07-18T10:15:36Z"),
+		Address:   &domain.Address{Pref: "Tokyo", Street: "1-2", Date: domain.Date1{Year: 2021}},
+	}, user); len(diff) != 0 {
+		t.Errorf("Compare value is mismatch(-:expected, +:actual) :%s\n", diff)
+	}
+
+	_, err = sesame.NewReflectMapper[model.UserModel, domain.Todo](mappers, sesame.ReflectMapping{})
+	if err == nil || err.Error() != "Unmapped field: 'model.UserModel.Name'" {
+		t.Errorf("unmapped fields should be an error: %v", err)
+	}
+	if _, err = sesame.NewReflectMapper[model.UserModel, domain.Todo](mappers, sesame.ReflectMapping{
+		AllowUnmapped: true,
+	}); err != nil {
+		t.Errorf("unmapped fields should be allowed: %v", err)
+	}
+}
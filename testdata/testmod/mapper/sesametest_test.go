@@ -0,0 +1,172 @@
+package mapper_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/yuin/sesame"
+	"github.com/yuin/sesame/sesametest"
+
+	"example.com/testmod/domain"
+	"example.com/testmod/mapper"
+	. "example.com/testmod/mapper"
+	"example.com/testmod/model"
+)
+
+type fakeTB struct {
+	testing.TB
+	errors []string
+}
+
+func (t *fakeTB) Helper() {}
+
+func (t *fakeTB) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder(t *testing.T) {
+	rec := sesametest.NewRecorder(sesame.NewMappers())
+	if err := sesametest.AddConverters(rec); err != nil {
+		t.Fatal(err)
+	}
+	mapper.AddStreetConverter(rec)
+
+	NewUserMapper(rec)
+	calls := rec.Calls()
+	if len(calls) == 0 || calls[0].Method != "Get" || calls[0].ID != "UserMapperHelper" {
+		t.Fatalf("Get should be recorded: %+v", calls)
+	}
+	found := false
+	for _, c := range calls {
+		if c.Method == "GetFuncByTypeName" && c.SourceType == "string" && c.DestType == "time#Time" {
+			found = true
+			if c.Err != nil {
+				t.Errorf("TimeStringConverter should be found: %v", c.Err)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("lookups by the UserMapper factory should be recorded: %+v", calls)
+	}
+
+	rec.Reset()
+	if len(rec.Calls()) != 0 {
+		t.Error("Reset should clear calls")
+	}
+	if _, err := rec.GetFunc("", reflect.TypeOf(""), reflect.TypeOf(&time.Time{})); err != nil {
+		t.Fatal(err)
+	}
+	if calls := rec.Calls(); len(calls) != 1 || calls[0].SourceType != "string" || calls[0].DestType != "time#Time" {
+		t.Errorf("GetFunc should record type names like GetFuncByTypeName: %+v", calls)
+	}
+	rec.Reset()
+	_, err := rec.Get("Unknown")
+	if calls := rec.Calls(); len(calls) != 1 || calls[0].Err != err || err == nil {
+		t.Errorf("errors should be recorded: %+v", calls)
+	}
+}
+
+func TestAssertRoundTrip(t *testing.T) {
+	mappers := NewMappers()
+	if err := sesametest.AddConverters(mappers); err != nil {
+		t.Fatal(err)
+	}
+	mapper.AddStreetConverter(mappers)
+	userMapper, err := sesame.Get[UserMapper](mappers, UserMapperID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &model.UserModel{
+		ID:        "id1",
+		Name:      "alice",
+		UpdatedAt: "2024-07-18T10:15:36Z",
+		Address: &model.AddressModel{
+			Pref:      "Tokyo",
+			Street:    []int{1, 2},
+			IntValues: []int{3},
+		},
+	}
+	sesametest.AssertRoundTrip(t, userMapper, user)
+	sesametest.AssertRoundTrip(t, userMapper, &domain.User{ID: "id1", UpdatedAt: mustTime("2024-07-18T10:15:36Z")},
+		sesametest.WithDiff(func(x, y any) string { return cmp.Diff(x, y) }))
+
+	// Times are truncated to seconds by RFC3339.
+	ft := &fakeTB{TB: t}
+	sesametest.AssertRoundTrip(ft, userMapper, &domain.User{UpdatedAt: mustTime("2024-07-18T10:15:36Z").Add(time.Millisecond)})
+	if len(ft.errors) != 1 {
+		t.Errorf("a changed object should be an error: %v", ft.errors)
+	}
+	ft = &fakeTB{TB: t}
+	sesametest.AssertRoundTrip(ft, userMapper, &domain.User{UpdatedAt: mustTime("2024-07-18T10:15:36Z").Add(time.Millisecond)},
+		sesametest.WithIgnoreFields("UpdatedAt"))
+	if len(ft.errors) != 0 {
+		t.Errorf("ignored fields should not be compared: %v", ft.errors)
+	}
+
+	ft = &fakeTB{TB: t}
+	sesametest.AssertRoundTrip(ft, userMapper, &model.TodoModel{})
+	if len(ft.errors) != 1 {
+		t.Errorf("mappers without functions for A should be an error: %v", ft.errors)
+	}
+}
+
+func TestAssertAllConvertersRegistered(t *testing.T) {
+	mappers := NewMappers()
+	mapper.AddTimeToStringConverter(mappers)
+	ft := &fakeTB{TB: t}
+	sesametest.AssertAllConvertersRegistered(ft, mappers)
+	if len(ft.errors) < 2 {
+		t.Fatalf("each missing converter should be reported: %v", ft.errors)
+	}
+	for _, e := range ft.errors {
+		if !strings.HasPrefix(e, "not registered: ") {
+			t.Errorf("unexpected error: %s", e)
+		}
+	}
+
+	mappers = sesame.NewMappers()
+	if err := sesametest.AddConverters(mappers); err != nil {
+		t.Fatal(err)
+	}
+	mapper.AddStreetConverter(mappers)
+	mapper.AddDate1Converter(mappers)
+	sesame.AddFactory(mappers, UserMapperID, func(mg sesame.MapperGetter) (UserMapper, error) {
+		return NewUserMapper(mg), nil
+	})
+	sesame.AddFactory(mappers, AddressMapperID, func(mg sesame.MapperGetter) (AddressMapper, error) {
+		return NewAddressMapper(mg), nil
+	})
+	sesametest.AssertAllConvertersRegistered(t, mappers)
+}
+
+func TestDeterministicConverters(t *testing.T) {
+	mappers := sesame.NewMappers()
+	mappers.Freeze()
+	if err := sesametest.AddConverters(mappers); !errors.Is(err, sesame.ErrFrozen) {
+		t.Errorf("AddConverters should return an error: %v", err)
+	}
+
+	ctx := context.TODO()
+	jst := time.FixedZone("JST", 9*60*60)
+	c := &sesametest.TimeStringConverter{}
+	s, _, err := c.TimeToString(ctx, &[]time.Time{time.Date(2024, 1, 1, 9, 0, 0, 0, jst)}[0])
+	if err != nil || s != "2024-01-01T00:00:00Z" {
+		t.Errorf("times should be formatted in UTC: %s, %v", s, err)
+	}
+
+	fc := &sesametest.FixedTimeStringConverter{Time: sesametest.FixedTime}
+	v, err := fc.StringToTime(ctx, &s)
+	if err != nil || !v.Equal(sesametest.FixedTime) {
+		t.Errorf("a fixed time should be returned: %v, %v", v, err)
+	}
+	s, _, err = fc.TimeToString(ctx, nil)
+	if err != nil || s != "2021-01-01T00:00:00Z" {
+		t.Errorf("a fixed time should be returned: %s, %v", s, err)
+	}
+}
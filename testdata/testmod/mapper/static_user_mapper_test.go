@@ -0,0 +1,88 @@
+package mapper_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"example.com/testmod/domain"
+	"example.com/testmod/mapper"
+	. "example.com/testmod/mapper"
+	"example.com/testmod/model"
+	"github.com/google/go-cmp/cmp"
+	"github.com/yuin/sesame"
+)
+
+func TestStaticUserMapper(t *testing.T) {
+	mappers := NewMappers()
+	mapper.AddTimeToStringConverter(mappers)
+	mappers.Add("StaticUserMapperHelper", &UpperCaseNameHelper{})
+	ctx := context.TODO()
+
+	userMapper, err := sesame.Get[StaticUserMapper](mappers, "StaticUserMapper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &model.UserModel{
+		ID:        "id1",
+		Name:      "name1",
+		UpdatedAt: "2024-07-18T10:15:36Z",
+	}
+	var entity domain.User
+	if err := userMapper.UserModelToUser(ctx, source, &entity); err != nil {
+		t.Fatal(err)
+	}
+	// FixedTimeStringConverter is bound statically, so UpdatedAt is always fixed.
+	if diff := cmp.Diff(&domain.User{
+		ID:        "id1",
+		Name:      "NAME1",
+		UpdatedAt: mustTime("2021-01-01T00:00:00Z"),
+	}, &entity); len(diff) != 0 {
+		t.Errorf("Compare value is mismatch(-:expected, +:actual) :%s\n", diff)
+	}
+
+	var model model.UserModel
+	if err := userMapper.UserToUserModel(ctx, &entity, &model); err != nil {
+		t.Fatal(err)
+	}
+	if model.Name != "name1" || model.UpdatedAt != "2021-01-01T00:00:00Z" {
+		t.Errorf("unexpected result: %+v", model)
+	}
+}
+
+func TestStaticUserMapperDeps(t *testing.T) {
+	// Static mappers can be constructed without Mappers.
+	userMapper := NewStaticUserMapper(NewMappers(), StaticUserMapperDeps{
+		FixedTimeStringConverter: &FixedTimeStringConverter{},
+	})
+	var entity domain.User
+	if err := userMapper.UserModelToUser(context.TODO(), &model.UserModel{Name: "name1"}, &entity); err != nil {
+		t.Fatal(err)
+	}
+	if entity.Name != "name1" || !entity.UpdatedAt.Equal(mustTime("2021-01-01T00:00:00Z")) {
+		t.Errorf("unexpected result: %+v", entity)
+	}
+}
+
+func TestStaticUserMapperWrongType(t *testing.T) {
+	mappers := NewMappers()
+	mappers.Add("FixedTimeStringConverter", &TimeStringConverter{})
+	if _, err := sesame.Get[StaticUserMapper](mappers, "StaticUserMapper"); err == nil {
+		t.Error("StaticUserMapper should not be created with a wrong type dependency")
+	}
+}
+
+func TestStaticUserMapperMissingDeps(t *testing.T) {
+	// Converters in 'uses' are required.
+	mappers := NewMappers()
+	if _, err := sesame.Get[StaticUserMapper](mappers, "StaticUserMapper"); !errors.Is(err, sesame.ErrNotFound) {
+		t.Errorf("StaticUserMapper should not be created without a required dependency: %v", err)
+	}
+
+	// A helper is optional.
+	mappers = NewMappers()
+	mapper.AddTimeToStringConverter(mappers)
+	if _, err := sesame.Get[StaticUserMapper](mappers, "StaticUserMapper"); err != nil {
+		t.Errorf("StaticUserMapper should be created without a helper: %v", err)
+	}
+}
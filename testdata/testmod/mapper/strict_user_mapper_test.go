@@ -0,0 +1,56 @@
+package mapper_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"example.com/testmod/domain"
+	"example.com/testmod/mapper"
+	. "example.com/testmod/mapper"
+	"example.com/testmod/model"
+	"github.com/google/go-cmp/cmp"
+	"github.com/yuin/sesame"
+)
+
+func TestStrictUserMapper(t *testing.T) {
+	ctx := context.TODO()
+	source := &model.UserModel{
+		ID:        "id1",
+		Name:      "name1",
+		UpdatedAt: "2024-07-18T10:15:36Z",
+	}
+
+	mappers := NewMappers()
+	userMapper, err := sesame.Get[StrictUserMapper](mappers, "StrictUserMapper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entity domain.User
+	err = userMapper.UserModelToUser(ctx, source, &entity)
+	if err == nil {
+		t.Fatal("strict mapper should fail without converters")
+	}
+	expected := "StrictUserMapper requires a global function for string -> time#Time, but it is not registered"
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("%q should contain %q", err.Error(), expected)
+	}
+
+	mappers = NewMappers()
+	mapper.AddTimeToStringConverter(mappers)
+	userMapper, err = sesame.Get[StrictUserMapper](mappers, "StrictUserMapper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entity = domain.User{}
+	if err := userMapper.UserModelToUser(ctx, source, &entity); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&domain.User{
+		ID:        "id1",
+		Name:      "name1",
+		UpdatedAt: mustTime("2024-07-18T10:15:36Z"),
+	}, &entity); len(diff) != 0 {
+		t.Errorf("Compare value is mismatch(-:expected, +:actual) :%s\n", diff)
+	}
+}
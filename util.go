@@ -14,7 +14,10 @@ func getType[T any]() reflect.Type {
 	return rt
 }
 
-func toTypeName(typ reflect.Type) string {
+// TypeName returns a name of given type used by
+// [MapperGetter.GetFuncByTypeName] like 'time#Time' .
+// A top-level pointer is ignored.
+func TypeName(typ reflect.Type) string {
 	if typ.Kind() == reflect.Ptr {
 		return toTypeNameAux(typ.Elem())
 	}
@@ -49,7 +52,7 @@ func toTypeNameAux(typ reflect.Type) string {
 }
 
 func toTypeIndex(prefix string, typ1, typ2 reflect.Type) string {
-	return toTypeIndexFromString(prefix, toTypeName(typ1), toTypeName(typ2))
+	return toTypeIndexFromString(prefix, TypeName(typ1), TypeName(typ2))
 }
 
 func toTypeIndexFromString(prefix string, typ1, typ2 string) string {